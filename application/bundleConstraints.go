@@ -0,0 +1,101 @@
+package application
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ivan-kostko/nrute-matches/domain"
+)
+
+// checkBundle checks whether movements assigned to the contract condition satisfy its bundle constraints.
+// Returns RejectionReasonNone if they do, otherwise the reason of rejection.
+func (m *matcher) checkBundle(cond *domain.ContractCondition, mvmts []Movement) RejectionReason {
+
+	if !isWithinTimeWindow(cond, mvmts) {
+		return RejectionReasonTimeWindow
+	}
+
+	if !isCoherent(cond, mvmts) {
+		return RejectionReasonIncoherent
+	}
+
+	if !isInSequence(cond, mvmts) {
+		return RejectionReasonOutOfSequence
+	}
+
+	return RejectionReasonNone
+}
+
+// isWithinTimeWindow checks that bundled movements happen within the contract condition MaxSpan
+// and are at least MinGap apart from each other.
+func isWithinTimeWindow(cond *domain.ContractCondition, mvmts []Movement) bool {
+
+	if cond.MaxSpan <= 0 && cond.MinGap <= 0 {
+		return true
+	}
+
+	dates := make([]time.Time, 0, len(mvmts))
+	for _, mvmt := range mvmts {
+		dates = append(dates, mvmt.Date)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	if cond.MaxSpan > 0 && dates[len(dates)-1].Sub(dates[0]) > cond.MaxSpan {
+		return false
+	}
+
+	if cond.MinGap > 0 {
+		for dateNo := 1; dateNo < len(dates); dateNo++ {
+			if dates[dateNo].Sub(dates[dateNo-1]) < cond.MinGap {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// isCoherent checks that bundled movements have equal values of all contract condition CoherentAttributes.
+func isCoherent(cond *domain.ContractCondition, mvmts []Movement) bool {
+
+	for _, attribute := range cond.CoherentAttributes {
+		first, _ := coherentAttributeValue(mvmts[0], attribute)
+		for _, mvmt := range mvmts[1:] {
+			if value, _ := coherentAttributeValue(mvmt, attribute); value != first {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// isInSequence checks that dates of ordered contract condition movements follow the order of movement activities.
+// mvmts are expected in the order of movement activities they are assigned to.
+func isInSequence(cond *domain.ContractCondition, mvmts []Movement) bool {
+
+	if !cond.Ordered {
+		return true
+	}
+
+	for mvmtNo := 1; mvmtNo < len(mvmts); mvmtNo++ {
+		if !mvmts[mvmtNo].Date.Add(cond.OrderTolerance).After(mvmts[mvmtNo-1].Date) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// coherentAttributeValue returns the value of the movement attribute and true if the attribute is known.
+func coherentAttributeValue(mvmt Movement, attribute string) (string, bool) {
+	switch attribute {
+	case domain.CoherentAttribute_VehicleId:
+		return mvmt.Vehicle.Id, true
+	case domain.CoherentAttribute_WorkflowId:
+		return mvmt.Workflow.Id, true
+	case domain.CoherentAttribute_UserId:
+		return mvmt.User.Id, true
+	}
+	return "", false
+}
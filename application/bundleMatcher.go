@@ -2,51 +2,168 @@ package application
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"sort"
 	"strconv"
+	"sync/atomic"
+	"time"
 
 	"github.com/ivan-kostko/nrute-matches/domain"
 )
 
-func MatchMovementsToBundleContractConditions(ctx context.Context, movements []Movement, conds []domain.ContractCondition) []Match {
-
-	mainLogger := new(log).WithFields(map[string]interface{}{"logger": "MatchMovementsToBundleContractConditions"})
+// MatchMovementsToBundleContractConditions matches movements to bundle contract conditions and returns the best scoring combination of matches.
+// Movements are matched to single activity contract conditions as well, while bundles win over single activity conditions scoring the same.
+// Movements left unmatched are returned as a Match without ContractCondition.
+// Scoring and tie-breaking can be configured via opts, e.g. WithScorer and WithTieBreakers.
+// Logs are written to the logger given by WithLogger, or carried by ctx, see ContextWithLogger, or to slog.Default().
+// If the tie between best scoring combinations remains unresolved, movements of the same contractor, branch and workflow type
+// as the tied ones are returned unmatched.
+// If ctx is done before the search is over, the best combination found so far is returned.
+// Input is not checked at all.
+// Use MatchMovements to find out about broken input, ties and interrupted search.
+func MatchMovementsToBundleContractConditions(ctx context.Context, movements []Movement, conds []domain.ContractCondition, opts ...Option) []Match {
+
+	m := &matcher{ctx: ctx, opts: newOptions(opts...)}
+
+	mainLogger := m.newLog().WithFields(map[string]interface{}{"logger": "MatchMovementsToBundleContractConditions"})
 	mainLogger.Info("MatchMovementsToBundleContractConditions invoked")
 
-	mainLogger.Debug("Getting all combinations")
+	result, _ := m.match(mainLogger, movements, conds, false)
+
+	return result.Matches()
+}
+
+// MatchMovements is the v2 entry point of matching movements to bundle and single activity contract conditions.
+// It returns the best scoring combination of matches along with the information about a tie between best scoring combinations
+// and the reasons why unmatched movements were rejected by each contract condition.
+//
+// Returned error tells the caller what went wrong:
+//   - ErrInvalidCondition, ErrInvalidMovement or ErrDuplicateMovement if input is broken, e.g. contract conditions are priced
+//     in different currencies along with ObjectiveRevenue or ObjectiveCustomerCost. Nothing is matched then.
+//   - ErrPartialResult along with ctx.Err() or ErrSearchBudgetExceeded if the search was interrupted.
+//     The best combination found so far is returned then.
+//   - ErrAmbiguousResult if the tie between best scoring combinations remains unresolved.
+//     Movements of the same contractor, branch and workflow type as the tied ones are returned unmatched,
+//     while tied combinations are available for manual review.
+//
+// Finding the best combination takes time exponential in the number of movements which contract conditions combine in many ways.
+// Thousands of movements a day against hundreds of contract conditions are matched in about a second, as long as conditions
+// split them into small groups, e.g. by vehicle type and span. Beyond about two hundred movements a day of the same contractor,
+// branch, workflow type and vehicle type which could combine with each other, the search is not expected to be over.
+// Pass ctx with a deadline or use WithSearchBudget to get the best combination found in time along with ErrPartialResult.
+func MatchMovements(ctx context.Context, movements []Movement, conds []domain.ContractCondition, opts ...Option) (MatchResult, error) {
+
+	m := &matcher{ctx: ctx, opts: newOptions(opts...)}
+
+	mainLogger := m.newLog().WithFields(map[string]interface{}{"logger": "MatchMovements"})
+	mainLogger.Info("MatchMovements invoked")
+
+	if err := checkInput(movements, conds, m.opts.objectives); err != nil {
+		mainLogger.Warn("Input is broken: " + err.Error())
+		return MatchResult{Unmatched: movements}, err
+	}
+
+	result, err := m.match(mainLogger, movements, conds, true)
+	if err != nil {
+		return result, err
+	}
+
+	if result.IsTie && !result.IsTieResolved {
+		return result, ErrAmbiguousResult
+	}
+
+	return result, nil
+}
+
+// match matches movements to contract conditions.
+// Movements are split into partitions by contractor, branch and workflow type, which are matched separately and merged.
+// Rejections of unmatched movements are explained only if isExplained is set, as they are costly to find out.
+// Returns an error only if the search was interrupted, along with the best result found so far.
+func (m *matcher) match(logger Log, movements []Movement, conds []domain.ContractCondition, isExplained bool) (MatchResult, error) {
+
+	// Conditions are copied once, so matches and rejections do not point into input, while the same condition shares the pointer.
+	conds = append([]domain.ContractCondition(nil), conds...)
+
+	partitions, unpartitioned := splitIntoPartitions(movements, conds)
+
+	logger.Debug("Movements are split into " + strconv.Itoa(len(partitions)) + " partitions, " + strconv.Itoa(len(unpartitioned)) + " movements fit no contract condition")
+
+	results := m.matchPartitions(logger, partitions)
+
+	result := mergePartitionResults(movements, partitions, results, unpartitioned)
+
+	if len(result.Bundles) == 0 {
+		logger.Info("No (best)matche(s) found. The best is just unmatched movements")
+		result.Unmatched = movements
+		result.Score = 0
+	}
+
+	// ctx might get done while explaining rejections, which does not affect the result, so the interruption of the search is captured beforehand.
+	err := m.interruption()
+
+	if isExplained {
+		logger.Debug("Explaining rejections of unmatched movements")
+		result.Rejections = m.explainRejections(logger, movements, result.Unmatched, result.Bundles, conds, tiedMovementIds(partitions, results))
+	}
+
+	if err != nil {
+		logger.Warn("The search was interrupted. Returning partial result")
+		return result, err
+	}
+
+	return result, nil
+}
+
+// matchPartition matches movements of the partition to its contract conditions within budget steps of the search, zero meaning unlimited.
+// Rejections are left to be explained for all movements at once.
+func (m *matcher) matchPartition(logger Log, p partition, budget int) MatchResult {
+
+	logger.Debug("Searching for the best combinations")
 
-	combinations := getMatchingCombinations(mainLogger, movements, conds)
+	combinations := newSearch(m, p.movements, p.conds, budget).run(logger)
 
-	mainLogger.Debug("Selecting the best from combinations")
+	logger.Debug("Selecting the best from combinations")
 
-	theBest := selectBestMatchCombination(mainLogger, combinations)
+	result := m.selectBestMatchCombination(logger, combinations)
 
-	if len(theBest) == 0 {
-		mainLogger.Info("No (best)matche(s) found. The best is just unmatched movements")
-		theBest = []Match{Match{Movements: movements}}
+	if len(result.Bundles) == 0 {
+		logger.Info("No (best)matche(s) found in partition. The best is just unmatched movements")
+		result.Unmatched = p.movements
+		result.Score = 0
 	}
 
-	return theBest
+	return result
 }
 
-func selectBestMatchCombination(logger Log, combinations [][]Match) []Match {
+// selectBestMatchCombination selects the best scoring combination, or the best one by configured objectives.
+// Combinations with higher total priority of contract conditions are preferred regardless of the rest, unless priority is weighted.
+// Ties between best scoring combinations are resolved by configured tie breakers.
+// Returns result without Bundles if there are no combinations or the tie remains unresolved.
+func (m *matcher) selectBestMatchCombination(logger Log, combinations [][]Match) MatchResult {
 
 	if len(combinations) == 0 {
 		logger.Info("No combinations provided for selecting the best one. Returning")
-		return nil
+		return MatchResult{}
 	}
 
-	logger.Debug("Selecting the best combination from:", combinations)
+	logger.Debug("Selecting the best combination", slog.Any("combinations", combinationSet(combinations)))
 
 	winners := struct {
+		BestRank     rank
 		BestScore    int
 		Combinations [][]Match
+		// ConditionSets holds keys of contract conditions matched by Combinations.
+		ConditionSets map[string]bool
 	}{}
 
+	condNos := map[*domain.ContractCondition]int{}
+
 	for combinationNo, combination := range combinations {
 
 		combinationLogger := logger.WithFields(map[string]interface{}{"combination_no": combinationNo})
 
-		combinationLogger.Info("Selecting best combination")
+		combinationLogger.Debug("Selecting best combination")
 		combinationLogger.Debug("Combination score set to zero")
 		combinationScore := 0
 
@@ -63,250 +180,343 @@ func selectBestMatchCombination(logger Log, combinations [][]Match) []Match {
 			matchLogger.Debug("Current combination score is " + strconv.Itoa(combinationScore))
 		}
 
-		if combinationScore == winners.BestScore {
+		// Priority of contract conditions goes before or into score, so combinations are compared by rank.
+		combinationRank := m.rankOf(combination)
+
+		if len(winners.Combinations) > 0 && combinationRank.isEqual(winners.BestRank) {
+
+			// Combinations billing the same contract conditions are interchangeable, so the first found one is kept.
+			key := conditionSetKey(combination, condNos)
+			if winners.ConditionSets[key] {
+				combinationLogger.Debug("Current combination has same score and contract conditions as some in before. Skipping it")
+				continue
+			}
+
 			combinationLogger.Debug("Current combination has same score as some in before. Adding to potential winner(s)")
 			winners.Combinations = append(winners.Combinations, combination)
+			winners.ConditionSets[key] = true
 
 		}
 
-		if combinationScore > winners.BestScore {
+		// The first combination is the winner so far, even if its rank is below zero due to negative priority.
+		if len(winners.Combinations) == 0 || combinationRank.isBetter(winners.BestRank) {
 			combinationLogger.Debug("Current combination is better than any in before. Selecting as potential winner")
 			winners.Combinations = [][]Match{combination}
+			winners.ConditionSets = map[string]bool{conditionSetKey(combination, condNos): true}
+			winners.BestRank = combinationRank
 			winners.BestScore = combinationScore
 
 		}
 
 	}
 
+	result := MatchResult{Score: winners.BestScore}
+
+	// More specific patterns rank higher, so combinations dominated by them are not tied with the rest.
+	if len(winners.Combinations) > 1 {
+		winners.Combinations = keepDominatingPatterns(winners.Combinations)
+	}
+
 	if len(winners.Combinations) > 1 {
-		logger.WithFields(map[string]interface{}{"winners_best_score": winners.BestScore}).Warn("More than one combination has the best score")
-		return nil
+		tieLogger := logger.WithFields(map[string]interface{}{"winners_best_score": winners.BestScore})
+		tieLogger.Warn("More than one combination has the best score. Breaking the tie")
+
+		result.IsTie = true
+		result.TiedCombinations = winners.Combinations
+
+		winners.Combinations = breakTie(winners.Combinations, m.opts.tieBreakers)
+
+		if len(winners.Combinations) > 1 {
+			tieLogger.Warn("The tie could not be resolved. Leaving tied combinations for manual review")
+			return result
+		}
+
+		result.IsTieResolved = true
 	}
 
 	// There should be one-and-only-one combination, cause casewith 0 combinations was excluded in the beginning of the func
 
 	logger.WithFields(map[string]interface{}{"winners_best_score": winners.BestScore}).Info("The winner successfully selected")
-	logger.Debug("The winner is: ", winners.Combinations[0])
-	return winners.Combinations[0]
+	logger.Debug("The winner is selected", slog.Any("winner", combination(winners.Combinations[0])))
+
+	result.Bundles, result.Unmatched = splitCombination(winners.Combinations[0])
+	return result
 
 }
 
-func getMatchingCombinations(logger Log, movements []Movement, conds []domain.ContractCondition) [][]Match {
+// conditionSetKey builds an order independent key of contract conditions matched in the combination.
+// Matches to the same condition share the pointer, so conditions are numbered by condNos on first sight instead of being compared by value.
+func conditionSetKey(combination []Match, condNos map[*domain.ContractCondition]int) string {
 
-	logger.Info("getMatchingCombinations invoked with the following params:\r\n", movements, conds)
+	nos := []int{}
+	for _, cond := range conditionsOf(combination) {
+		condNo, ok := condNos[cond]
+		if !ok {
+			condNo = len(condNos)
+			condNos[cond] = condNo
+		}
+		nos = append(nos, condNo)
+	}
+	sort.Ints(nos)
 
-	const (
-		VehicleTypeDirectMatchScore              = 3
-		VehicleTypeFallbackMatchScore            = 0
-		WorkflowFactorDirectMatchScore           = 2
-		WorkflowFactorFallbackMatchScore         = 0
-		MovementActivityOptionDirectMatchScore   = 1
-		MovementActivityOptionFallbackMatchScore = 0
-	)
+	key := ""
+	for _, condNo := range nos {
+		key += strconv.Itoa(condNo) + ","
+	}
+	return key
+}
+
+// matcher matches movements to contract conditions according to its options.
+type matcher struct {
+	// ctx interrupts the search once done.
+	ctx  context.Context
+	opts *options
+	// isBudgetExceeded tells whether the search of some partition exceeded its share of the search budget.
+	isBudgetExceeded atomic.Bool
+}
 
-	// Represents set of match combinations as the result returned by this function.
-	// It will be appended on every success iteration.
-	resultMatchCombinations := [][]Match{}
+// newLog returns Log of the matching run, writing to the logger given by WithLogger,
+// or carried by ctx, or to slog.Default() otherwise.
+func (m *matcher) newLog() Log {
 
-	for condNo, cond := range conds {
+	logger := m.opts.logger
+	if logger == nil {
+		logger = LoggerFromContext(m.ctx)
+	}
 
-		condLogger := logger.WithFields(map[string]interface{}{"contract_condition_id": cond.Id, "contract_condition_name": cond.Name})
+	return newContextLog(m.ctx, logger)
+}
 
-		// Skipping non bundles
-		if !(len(cond.MovementActivities) > 1) {
-			condLogger.Info("ContractCondition is skipped because number of movement activities (" + strconv.Itoa(len(cond.MovementActivities)) + ") is less than 2, so it is not a Bundle at all.")
-			continue
-		}
+// interruption returns the reason why matching got interrupted, if any: ctx is done, or the search of some partition exceeded its budget.
+func (m *matcher) interruption() error {
 
-		// Represents set of movements which have not been matched yet.
-		// Due to rearrangements of `unmatchedMovementLeftovers` it is better to copy original `movements`.
-		unmatchedMovementLeftovers := append([]Movement{}, movements...)
+	if err := m.ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrPartialResult, err)
+	}
 
-		// Skip CC if it has more MA than movements at all.
-		// It wont match anyway...
-		if len(cond.MovementActivities) > len(unmatchedMovementLeftovers) {
-			condLogger.Debug("ContractCondition is skipped because number of movement activities (" + strconv.Itoa(len(cond.MovementActivities)) + ") is more than number of matching movements(" + strconv.Itoa(len(unmatchedMovementLeftovers)) + "), so wont match at all.")
-			continue
+	if m.isBudgetExceeded.Load() {
+		return fmt.Errorf("%w: %w", ErrPartialResult, ErrSearchBudgetExceeded)
+	}
+
+	return nil
+}
+
+// forEachAssignment calls fn for every complete assignment of distinct movements to all movement activities of the contract condition,
+// along with the total score of the assignment. assignment holds movement numbers grouped by movement activity in their order
+// and is reused between calls.
+// Every activity gets between its minimal and maximal number of movements, trying the largest number first,
+// so the fullest assignment of the same score is found first. At least one movement is assigned in total.
+// Each assigned movement contributes its own score, so repeated and optional activities do not favour or penalize bundles
+// over matching the same movements to activities one by one.
+// The search backtracks over movement-to-activity assignments, so no activity can steal the only fitting movement of another one.
+// Assignments might explode for conditions with many activities, so interrupted is checked on every step.
+// Returns false if it was interrupted before going through all assignments.
+func (m *matcher) forEachAssignment(logger Log, movements []Movement, cond *domain.ContractCondition, interrupted func() bool, fn func(assignment []int, score int)) bool {
+
+	type candidate struct {
+		mvmtNo int
+		score  ActivityScore
+	}
+
+	// Collect fitting movements for each movement activity upfront.
+	candidates := make([][]candidate, len(cond.MovementActivities))
+
+	for maNo, ccma := range cond.MovementActivities {
+
+		// Assignments are looked for once per group of coherent movements, so fields are added only if debug messages are written.
+		ccmaLogger := logger
+		if isDebugEnabled(logger) {
+			ccmaLogger = logger.WithFields(map[string]interface{}{"movement_activity_type": ccma.Type, "movement_activity_option": ccma.Option})
+			ccmaLogger.Debug("Starting to match movements to current activity")
 		}
 
-		condLogger.Info("Starting to find matches for contract condition")
-
-		// The flag indicates whether current contract condition has movement activity w/o corresponding movement.
-		ccHasUnmatchedMA := false
-
-		condLogger.Debug("Movements to exercise: ", unmatchedMovementLeftovers)
-
-		// Allocate a new copy of cond and reference to it while &cond - will point to variable cond which is getting new content on each iteration.
-		// So, &cond at the end on this function will point to cond variable, which will have content of last iterated contract condition.
-		conditionCopy := cond
-
-		// In case of successfull matching it will be appended to resultMatchCombinations at the very end of iteration.
-		// Meanwhile, on each MA 2 MVMT match it will be updated.
-		currentCcMatch := Match{ContractCondition: &conditionCopy}
-
-		// Loop over CCs MovementActivities
-		for _, ccma := range cond.MovementActivities {
-
-			ccmaLogger := condLogger.WithFields(map[string]interface{}{"movement_activity_type": ccma.Type, "movement_activity_option": ccma.Option})
-			ccmaLogger.Debug("Starting to match movement to current activity")
-
-			// Lets be objective - it is not matched yet.
-			maHasMatched := false
-
-			// Loop over `movements` in order to find matches
-			for mvmtNo, mvmt := range unmatchedMovementLeftovers {
-
-				mvmtLogger := ccmaLogger.WithFields(map[string]interface{}{"movement_id": mvmt.Id})
-				mvmtLogger.Info("Matching movement to CC MA")
-
-				// Extract contractor identifier from movement.
-				// It is needed later to check if movement fits cc.
-				mvmtContractorId := ""
-				if mvmt.User.Contractor != nil {
-					mvmtContractorId = *(mvmt.User.Contractor)
-				}
-
-				doesnotMatch := false
-				switch {
-				case cond.ContractorIdentifier != mvmtContractorId:
-					mvmtLogger.Debug("Movement ContractorId (" + mvmtContractorId + "does not match CC ContractorIdentifier (" + cond.ContractorIdentifier + ")")
-					doesnotMatch = true
-					fallthrough
-				case cond.BranchIdentifier != mvmt.Branch.Id:
-					mvmtLogger.Debug("Movement Branch.Id (" + mvmt.Branch.Id + ") does not match CC BranchIdentifier (" + cond.BranchIdentifier + ")")
-					doesnotMatch = true
-					fallthrough
-				case cond.WorkflowType != mvmt.Workflow.Type:
-					mvmtLogger.Debug("Movement Workflow.Type (" + mvmt.Workflow.Type + "does not match CC WorkflowType (" + cond.WorkflowType + ")")
-					doesnotMatch = true
-					fallthrough
-				case ccma.Type != mvmt.Type:
-					mvmtLogger.Debug("Movement Type (" + mvmt.Type + "does not match CC MA Type (" + ccma.Type + ")")
-					doesnotMatch = true
-					// Add more checks like for CC validity date, etc...
-				}
-				// Skip if doesn't match.
-				if doesnotMatch {
-					mvmtLogger.Debug("Movement does not match by main properties")
-					continue
-				}
-
-				// Check for VehicleType match
-				switch {
-				case cond.VehicleType == mvmt.Vehicle.Type:
-					mvmtLogger.Debug("Movement Vehicle.Type directly matches to contract condition VehicleType")
-					currentCcMatch.Score += VehicleTypeDirectMatchScore
-				case cond.VehicleType == domain.Undefined_VehicleType:
-					mvmtLogger.Debug("Movement Vehicle.Type matches to fallback")
-					currentCcMatch.Score += VehicleTypeFallbackMatchScore
-				default:
-					// This contract condition wont match, cause VehicleType does not match neither movement nor fallback
-					mvmtLogger.Debug("Movement VehicleType does not match neither ContractCondition nor fallback. Movement is skipped")
-					continue
-				}
-
-				// Check for WorkflowFactor match
-				switch {
-				case cond.WorkflowFactor == mvmt.Workflow.Factor:
-					mvmtLogger.Debug("Movement Workflow.Factor directly matches to contract condition WorkflowFactor")
-					currentCcMatch.Score += WorkflowFactorDirectMatchScore
-				case cond.WorkflowFactor == domain.Undefined_WorkflowFactor:
-					mvmtLogger.Debug("Movement  Workflow.Factor matches to fallback")
-					currentCcMatch.Score += WorkflowFactorFallbackMatchScore
-				default:
-					// This contract condition wont match, cause VehicleType does not match neither movement nor fallback
-					mvmtLogger.Debug("Movement WorkflowFactor does not match neither ContractCondition nor fallback. Movement is skipped")
-					continue
-				}
-
-				// Check for Option match
-				switch {
-				case ccma.Option == mvmt.Option:
-					mvmtLogger.Debug("Movement Option directly matches to contract condition movement activity option")
-					currentCcMatch.Score += MovementActivityOptionDirectMatchScore
-				case ccma.Option == domain.Undefined_MovementOption:
-					mvmtLogger.Debug("Movement  Option matches to fallback")
-					currentCcMatch.Score += MovementActivityOptionFallbackMatchScore
-				default:
-					// This contract condition wont match, cause VehicleType does not match neither movement nor fallback
-					mvmtLogger.Debug("Movement Option does not match neither ContractCondition nor fallback. Movement is skipped")
-					continue
-				}
-
-				mvmtLogger.Info("Movement matched to contract condition movement activity. Appending it to current match and removing it from unmatched.")
-
-				// Remove current move from unmatched
-				unmatchedMovementLeftovers = append(unmatchedMovementLeftovers[:mvmtNo], unmatchedMovementLeftovers[mvmtNo+1:]...)
-
-				mvmtLogger.Debug("Unmatched leftovers: ", unmatchedMovementLeftovers)
-
-				// Append current match to movements
-				currentCcMatch.Movements = append(currentCcMatch.Movements, mvmt)
-
-				// Indicate that MA has matched.
-				maHasMatched = true
-
-				break
+		for mvmtNo, mvmt := range movements {
+			if score, reason := m.matchMovementToActivity(ccmaLogger, cond, ccma, mvmt); reason == RejectionReasonNone {
+				candidates[maNo] = append(candidates[maNo], candidate{mvmtNo: mvmtNo, score: score})
 			}
+		}
 
-			if !maHasMatched {
-				// Means no movement matches MA - deal with it!
-				ccmaLogger.Info("Noone movement matches movement activity")
-				ccHasUnmatchedMA = true
-				break
-			}
+		if min, _ := activityCardinality(ccma); len(candidates[maNo]) < min {
+			// Means not enough movements match MA - deal with it!
+			ccmaLogger.Debug("Not enough movements match movement activity")
+			return true
+		}
 
+		// Movements of a repeated activity are taken in order of candidates, so for ordered conditions they have to follow their dates.
+		if cond.Ordered {
+			sort.SliceStable(candidates[maNo], func(i, j int) bool {
+				return movements[candidates[maNo][i].mvmtNo].Date.Before(movements[candidates[maNo][j].mvmtNo].Date)
+			})
 		}
+	}
 
-		if ccHasUnmatchedMA {
-			condLogger.Info("Skipping contract condition while there are unmatched movement activities")
-			continue
+	used := make([]bool, len(movements))
+	assignment := []int{}
+	isInterrupted := false
+
+	var assign func(maNo int, score int)
+	var assignActivity func(maNo int, fromCandidateNo int, count int, score int)
+
+	assign = func(maNo int, score int) {
+
+		if isInterrupted || interrupted() {
+			isInterrupted = true
+			return
 		}
 
-		condLogger.Debug("Current ContractCondition match: ", currentCcMatch)
+		if maNo == len(cond.MovementActivities) {
+			if len(assignment) > 0 {
+				fn(assignment, score)
+			}
+			return
+		}
 
-		// All previously checked contract conditions will appear in resultMatchCombinations if matched.
-		// So, only further/leftover conditions should be checked for matching to unmatched movements
-		conditionLeftovers := conds[condNo+1:]
+		min, max := activityCardinality(cond.MovementActivities[maNo])
+		for count := max; count >= min; count-- {
+			assignActivity(maNo, 0, count, score)
+		}
+	}
 
-		condLogger.Info("Contract Condition matched to some movements. Calling to match the following leftovers: ", unmatchedMovementLeftovers, conditionLeftovers)
+	// assignActivity picks count movements for the movement activity out of candidates starting from fromCandidateNo.
+	// Candidates are picked in their order, so the same movements are not assigned to the activity twice in a different order.
+	assignActivity = func(maNo int, fromCandidateNo int, count int, score int) {
 
-		leftoverCombinations := [][]Match{}
+		if isInterrupted {
+			return
+		}
 
-		if len(unmatchedMovementLeftovers) > 0 && len(conditionLeftovers) > 0 {
-			condLogger.Info("Tere are movements and CCs left. Calling to match leftovers")
-			leftoverCombinations = getMatchingCombinations(logger, unmatchedMovementLeftovers, conditionLeftovers)
+		if count == 0 {
+			assign(maNo+1, score)
+			return
 		}
 
-		condLogger.Debug("Leftover combinations are as the following: ", leftoverCombinations)
+		activityCandidates := candidates[maNo]
+		for candidateNo := fromCandidateNo; candidateNo <= len(activityCandidates)-count; candidateNo++ {
 
-		if len(leftoverCombinations) > 0 {
-			condLogger.Debug("There are matched leftovers. Adding them with current match to result")
-			for _, leftoverCombination := range leftoverCombinations {
-				// Appending resultMatchCombinations with leftoverCombination in conjunction with current match
-				resultMatchCombinations = append(resultMatchCombinations, append(leftoverCombination, currentCcMatch))
+			c := activityCandidates[candidateNo]
+			if used[c.mvmtNo] {
+				continue
 			}
+			used[c.mvmtNo] = true
+			assignment = append(assignment, c.mvmtNo)
 
-			// Due to fact that any match is better than unmatched movements, ther is no reason to add current match in conjunction to unmatched movements.
-			// Also, it could bring problems in case of same score with some match and zero score. So, just moving to next CC.
+			// The pair is accepted, so its score is committed.
+			assignActivity(maNo, candidateNo+1, count-1, score+c.score.Total())
 
-			continue
+			assignment = assignment[:len(assignment)-1]
+			used[c.mvmtNo] = false
 		}
+	}
+
+	assign(0, 0)
+
+	return !isInterrupted
+}
 
-		condLogger.Debug("There are no matched leftovers.")
+// matchMovementToActivity checks whether the movement fits to the contract condition movement activity.
+// Returns the standalone score of the pair and RejectionReasonNone if it does, otherwise zero score and the reason of rejection.
+func (m *matcher) matchMovementToActivity(logger Log, cond *domain.ContractCondition, ccma domain.MovementActivity, mvmt Movement) (ActivityScore, RejectionReason) {
 
-		// While there were no matched leftovers, for consistency it should return current match with unmatched movements.
-		currentCombination := []Match{currentCcMatch}
-		if len(unmatchedMovementLeftovers) > 0 {
+	isDebug := isDebugEnabled(logger)
 
-			condLogger.Debug("Adding unmatched movements to current match combination")
+	mvmtLogger := logger
+	if isDebug {
+		mvmtLogger = logger.WithFields(map[string]interface{}{"movement_id": mvmt.Id})
+		mvmtLogger.Debug("Matching movement to CC MA")
+	}
+
+	// Extract contractor identifier from movement.
+	// It is needed later to check if movement fits cc.
+	mvmtContractorId := ""
+	if mvmt.User.Contractor != nil {
+		mvmtContractorId = *(mvmt.User.Contractor)
+	}
 
-			currentCombination = append(currentCombination, Match{Movements: unmatchedMovementLeftovers})
+	reason := RejectionReasonNone
+	switch {
+	case cond.ContractorIdentifier != mvmtContractorId:
+		reason = RejectionReasonContractorMismatch
+	case cond.BranchIdentifier != mvmt.Branch.Id:
+		reason = RejectionReasonBranchMismatch
+	case cond.WorkflowType != mvmt.Workflow.Type:
+		reason = RejectionReasonWorkflowTypeMismatch
+	case ccma.Type != mvmt.Type:
+		reason = RejectionReasonMovementActivityTypeMismatch
+	case !m.isWithinValidity(cond, mvmt):
+		reason = RejectionReasonOutOfValidity
+		// Add more checks here...
+	}
+	// Skip if doesn't match.
+	if reason != RejectionReasonNone {
+		if isDebug {
+			mvmtLogger.Debug("Movement does not match by main properties ("+string(reason)+")", slog.Any("movement", mvmt))
+		}
+		return ActivityScore{}, reason
+	}
+
+	score, ok := m.opts.scorer.Score(cond, ccma, mvmt)
+	if !ok {
+		reason = RejectionReasonScorer
+		if explainer, isExplainer := m.opts.scorer.(RejectionExplainer); isExplainer {
+			reason = explainer.ExplainRejection(cond, ccma, mvmt)
 		}
+		if isDebug {
+			mvmtLogger.Debug("Movement does not match by " + string(reason) + ". Movement is skipped")
+		}
+		return ActivityScore{}, reason
+	}
+
+	if isDebug {
+		mvmtLogger.Debug("Movement matched to contract condition movement activity with score " + strconv.Itoa(score.Total()))
+	}
+
+	return score, RejectionReasonNone
+}
+
+// isWithinValidity checks whether the movement date falls into the contract condition validity period.
+// The movement date is taken as wall clock time of the movement branch, if its location is configured.
+func (m *matcher) isWithinValidity(cond *domain.ContractCondition, mvmt Movement) bool {
 
-		resultMatchCombinations = append(resultMatchCombinations, currentCombination)
+	if cond.ValidFrom.IsZero() && cond.ValidTo.IsZero() {
+		return true
+	}
+
+	date := mvmt.Date
+	if loc, ok := m.opts.branchLocations[mvmt.Branch.Id]; ok && loc != nil {
+		date = date.In(loc)
+	}
 
+	mvmtWallClock := wallClock(date)
+
+	if !cond.ValidFrom.IsZero() && mvmtWallClock.Before(wallClock(cond.ValidFrom)) {
+		return false
+	}
+
+	if !cond.ValidTo.IsZero() && !mvmtWallClock.Before(wallClock(cond.ValidTo)) {
+		return false
+	}
+
+	return true
+}
+
+// wallClock returns the wall clock time of t regardless of its location, so times of different locations are compared by their clocks.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// splitCombination splits the combination into matches to contract conditions and unmatched movements.
+func splitCombination(combination []Match) ([]Match, []Movement) {
+
+	bundles := []Match{}
+	unmatched := []Movement{}
+
+	for _, match := range combination {
+		if match.ContractCondition == nil {
+			unmatched = append(unmatched, match.Movements...)
+			continue
+		}
+		bundles = append(bundles, match)
 	}
 
-	return resultMatchCombinations
+	return bundles, unmatched
 }
@@ -2,6 +2,9 @@ package application_test
 
 import (
 	"context"
+	"math/big"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -1266,6 +1269,100 @@ func TestMatchMovementsToBundleContractConditions(t *testing.T) {
 				},
 			},
 		},
+		{
+			Alias: `Activity does not steal the only fitting movement of another activity`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "132457",
+					Type:     "parking",
+					Option:   "vip",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+
+				application.Movement{
+					Id:       "132458",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "DoubleParking",
+					Name:                 "Double parking",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "parking",
+						},
+						{
+							Option: "vip",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			ExpectedMatches: []application.Match{
+				application.Match{
+					Movements: []application.Movement{
+						application.Movement{
+							Id:       "132458",
+							Type:     "parking",
+							Option:   "",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+
+						application.Movement{
+							Id:       "132457",
+							Type:     "parking",
+							Option:   "vip",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+					},
+					ContractCondition: &domain.ContractCondition{
+						Id:                   "DoubleParking",
+						Name:                 "Double parking",
+						WorkflowType:         "turnaround",
+						WorkflowFactor:       "standard",
+						VehicleType:          "car",
+						BranchIdentifier:     "6",
+						ContractorIdentifier: "987654",
+						MovementActivities: []domain.MovementActivity{
+							{
+								Option: "",
+								Type:   "parking",
+							},
+							{
+								Option: "vip",
+								Type:   "parking",
+							},
+						},
+					},
+					Score: 12,
+				},
+			},
+		},
 	}
 
 	for _, tCase := range testCases {
@@ -1419,3 +1516,6072 @@ func TestMatchMovementsToBundleContractConditions_OnSameScore(t *testing.T) {
 	}
 
 }
+
+func TestMatchMovementsToBundleContractConditions_ScoreDoesNotLeak(t *testing.T) {
+
+	testCases := []struct {
+		Alias           string
+		MovementsIn     []application.Movement
+		ConditionsIn    []domain.ContractCondition
+		ExpectedMatches []application.Match
+	}{
+		{
+			Alias: `Option mismatch does not leak vehicle type and workflow factor score`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "132456",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+
+				application.Movement{
+					Id:       "132457",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+
+				application.Movement{
+					Id:       "132458",
+					Type:     "parking",
+					Option:   "vip",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "VipTurnaround",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "vip",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			ExpectedMatches: []application.Match{
+				application.Match{
+					Movements: []application.Movement{
+						application.Movement{
+							Id:       "132456",
+							Type:     "checkin",
+							Option:   "",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+						application.Movement{
+							Id:       "132458",
+							Type:     "parking",
+							Option:   "vip",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+					},
+					ContractCondition: &domain.ContractCondition{
+						Id:                   "VipTurnaround",
+						Name:                 "Turnaround",
+						WorkflowType:         "turnaround",
+						WorkflowFactor:       "standard",
+						VehicleType:          "car",
+						BranchIdentifier:     "6",
+						ContractorIdentifier: "987654",
+						MovementActivities: []domain.MovementActivity{
+							{
+								Option: "",
+								Type:   "checkin",
+							},
+							{
+								Option: "vip",
+								Type:   "parking",
+							},
+						},
+					},
+					Score: 12,
+				},
+				application.Match{
+					Movements: []application.Movement{
+						application.Movement{
+							Id:       "132457",
+							Type:     "parking",
+							Option:   "",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+					},
+					ContractCondition: nil,
+					Score:             0,
+				},
+			},
+		},
+		{
+			Alias: `Workflow factor mismatch does not leak vehicle type score`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "132456",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "express"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+
+				application.Movement{
+					Id:       "132457",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+
+				application.Movement{
+					Id:       "132458",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "express"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "ExpressTurnaround",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "express",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			ExpectedMatches: []application.Match{
+				application.Match{
+					Movements: []application.Movement{
+						application.Movement{
+							Id:       "132456",
+							Type:     "checkin",
+							Option:   "",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "express"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+						application.Movement{
+							Id:       "132458",
+							Type:     "parking",
+							Option:   "",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "express"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+					},
+					ContractCondition: &domain.ContractCondition{
+						Id:                   "ExpressTurnaround",
+						Name:                 "Turnaround",
+						WorkflowType:         "turnaround",
+						WorkflowFactor:       "express",
+						VehicleType:          "car",
+						BranchIdentifier:     "6",
+						ContractorIdentifier: "987654",
+						MovementActivities: []domain.MovementActivity{
+							{
+								Option: "",
+								Type:   "checkin",
+							},
+							{
+								Option: "",
+								Type:   "parking",
+							},
+						},
+					},
+					Score: 12,
+				},
+				application.Match{
+					Movements: []application.Movement{
+						application.Movement{
+							Id:       "132457",
+							Type:     "parking",
+							Option:   "",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+					},
+					ContractCondition: nil,
+					Score:             0,
+				},
+			},
+		},
+		{
+			Alias: `Movement rejected for one activity and accepted for another is scored once`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "132457",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+
+				application.Movement{
+					Id:       "132458",
+					Type:     "parking",
+					Option:   "vip",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "DoubleParking",
+					Name:                 "Double parking",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "vip",
+							Type:   "parking",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			ExpectedMatches: []application.Match{
+				application.Match{
+					Movements: []application.Movement{
+						application.Movement{
+							Id:       "132458",
+							Type:     "parking",
+							Option:   "vip",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+						application.Movement{
+							Id:       "132457",
+							Type:     "parking",
+							Option:   "",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+					},
+					ContractCondition: &domain.ContractCondition{
+						Id:                   "DoubleParking",
+						Name:                 "Double parking",
+						WorkflowType:         "turnaround",
+						WorkflowFactor:       "standard",
+						VehicleType:          "car",
+						BranchIdentifier:     "6",
+						ContractorIdentifier: "987654",
+						MovementActivities: []domain.MovementActivity{
+							{
+								Option: "vip",
+								Type:   "parking",
+							},
+							{
+								Option: "",
+								Type:   "parking",
+							},
+						},
+					},
+					Score: 12,
+				},
+			},
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			ctx := context.Background()
+
+			actualMatches := application.MatchMovementsToBundleContractConditions(ctx, tCase.MovementsIn, tCase.ConditionsIn)
+
+			if !assert.ElementsMatch(t, tCase.ExpectedMatches, actualMatches) {
+				t.Log("Returned Combination: \r\n")
+
+				for mNo, m := range actualMatches {
+					t.Logf(" MatchNo: %d \r\n%#v\r\n", mNo, m)
+
+				}
+
+			}
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+
+}
+
+func TestMatchMovementsToBundleContractConditions_WithScorer(t *testing.T) {
+
+	testCases := []struct {
+		Alias           string
+		OptionsIn       []application.Option
+		MovementsIn     []application.Movement
+		ConditionsIn    []domain.ContractCondition
+		ExpectedMatches []application.Match
+	}{
+		{
+			Alias:     `Default weights prefer vehicle type`,
+			OptionsIn: []application.Option{},
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "132456",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+
+				application.Movement{
+					Id:       "132457",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "WF",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+				domain.ContractCondition{
+					Id:                   "VT",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			ExpectedMatches: []application.Match{
+				application.Match{
+					Movements: []application.Movement{
+						application.Movement{
+							Id:       "132456",
+							Type:     "checkin",
+							Option:   "",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+						application.Movement{
+							Id:       "132457",
+							Type:     "parking",
+							Option:   "",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+					},
+					ContractCondition: &domain.ContractCondition{
+						Id:                   "VT",
+						Name:                 "Turnaround",
+						WorkflowType:         "turnaround",
+						WorkflowFactor:       "",
+						VehicleType:          "car",
+						BranchIdentifier:     "6",
+						ContractorIdentifier: "987654",
+						MovementActivities: []domain.MovementActivity{
+							{
+								Option: "",
+								Type:   "checkin",
+							},
+							{
+								Option: "",
+								Type:   "parking",
+							},
+						},
+					},
+					Score: 8,
+				},
+			},
+		},
+		{
+			Alias:     `Custom weights prefer workflow factor`,
+			OptionsIn: []application.Option{application.WithScorer(application.NewWeightedScorer(application.ScoreWeights{VehicleTypeDirectMatch: 3, WorkflowFactorDirectMatch: 5, MovementActivityOptionDirectMatch: 1}))},
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "132456",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+
+				application.Movement{
+					Id:       "132457",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "WF",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+				domain.ContractCondition{
+					Id:                   "VT",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			ExpectedMatches: []application.Match{
+				application.Match{
+					Movements: []application.Movement{
+						application.Movement{
+							Id:       "132456",
+							Type:     "checkin",
+							Option:   "",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+						application.Movement{
+							Id:       "132457",
+							Type:     "parking",
+							Option:   "",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+					},
+					ContractCondition: &domain.ContractCondition{
+						Id:                   "WF",
+						Name:                 "Turnaround",
+						WorkflowType:         "turnaround",
+						WorkflowFactor:       "standard",
+						VehicleType:          "",
+						BranchIdentifier:     "6",
+						ContractorIdentifier: "987654",
+						MovementActivities: []domain.MovementActivity{
+							{
+								Option: "",
+								Type:   "checkin",
+							},
+							{
+								Option: "",
+								Type:   "parking",
+							},
+						},
+					},
+					Score: 12,
+				},
+			},
+		},
+		{
+			Alias: `Contractor weights prefer workflow factor`,
+			OptionsIn: []application.Option{application.WithScorer(&application.ContractorScorer{
+				Scorers: map[string]application.Scorer{
+					"987654": application.NewWeightedScorer(application.ScoreWeights{VehicleTypeDirectMatch: 3, WorkflowFactorDirectMatch: 5, MovementActivityOptionDirectMatch: 1}),
+				},
+			})},
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "132456",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+
+				application.Movement{
+					Id:       "132457",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "WF",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+				domain.ContractCondition{
+					Id:                   "VT",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			ExpectedMatches: []application.Match{
+				application.Match{
+					Movements: []application.Movement{
+						application.Movement{
+							Id:       "132456",
+							Type:     "checkin",
+							Option:   "",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+						application.Movement{
+							Id:       "132457",
+							Type:     "parking",
+							Option:   "",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+					},
+					ContractCondition: &domain.ContractCondition{
+						Id:                   "WF",
+						Name:                 "Turnaround",
+						WorkflowType:         "turnaround",
+						WorkflowFactor:       "standard",
+						VehicleType:          "",
+						BranchIdentifier:     "6",
+						ContractorIdentifier: "987654",
+						MovementActivities: []domain.MovementActivity{
+							{
+								Option: "",
+								Type:   "checkin",
+							},
+							{
+								Option: "",
+								Type:   "parking",
+							},
+						},
+					},
+					Score: 12,
+				},
+			},
+		},
+		{
+			Alias: `Other contractor weights are not applied`,
+			OptionsIn: []application.Option{application.WithScorer(&application.ContractorScorer{
+				Scorers: map[string]application.Scorer{
+					"123456": application.NewWeightedScorer(application.ScoreWeights{VehicleTypeDirectMatch: 3, WorkflowFactorDirectMatch: 5, MovementActivityOptionDirectMatch: 1}),
+				},
+			})},
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "132456",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+
+				application.Movement{
+					Id:       "132457",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "WF",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+				domain.ContractCondition{
+					Id:                   "VT",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			ExpectedMatches: []application.Match{
+				application.Match{
+					Movements: []application.Movement{
+						application.Movement{
+							Id:       "132456",
+							Type:     "checkin",
+							Option:   "",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+						application.Movement{
+							Id:       "132457",
+							Type:     "parking",
+							Option:   "",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+					},
+					ContractCondition: &domain.ContractCondition{
+						Id:                   "VT",
+						Name:                 "Turnaround",
+						WorkflowType:         "turnaround",
+						WorkflowFactor:       "",
+						VehicleType:          "car",
+						BranchIdentifier:     "6",
+						ContractorIdentifier: "987654",
+						MovementActivities: []domain.MovementActivity{
+							{
+								Option: "",
+								Type:   "checkin",
+							},
+							{
+								Option: "",
+								Type:   "parking",
+							},
+						},
+					},
+					Score: 8,
+				},
+			},
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			ctx := context.Background()
+
+			actualMatches := application.MatchMovementsToBundleContractConditions(ctx, tCase.MovementsIn, tCase.ConditionsIn, tCase.OptionsIn...)
+
+			assert.ElementsMatch(t, tCase.ExpectedMatches, actualMatches)
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+
+}
+
+func TestMatchMovements_TieBreakers(t *testing.T) {
+
+	movements := []application.Movement{
+		application.Movement{
+			Id:       "132456",
+			Type:     "checkin",
+			Option:   "option1",
+			Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+			Branch:   application.Branch{Id: "6"},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+			User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		},
+		application.Movement{
+			Id:       "132457",
+			Type:     "parking",
+			Option:   "option2",
+			Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+			Branch:   application.Branch{Id: "6"},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+			User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		},
+	}
+
+	conditions := []domain.ContractCondition{
+		domain.ContractCondition{
+			Id:                   "WF+Opts",
+			Name:                 "Turnaround",
+			WorkflowType:         "turnaround",
+			WorkflowFactor:       "standard",
+			VehicleType:          "",
+			BranchIdentifier:     "6",
+			ContractorIdentifier: "987654",
+			MovementActivities: []domain.MovementActivity{
+				{
+					Option: "option1",
+					Type:   "checkin",
+				},
+				{
+					Option: "option2",
+					Type:   "parking",
+				},
+			},
+		},
+		domain.ContractCondition{
+			Id:                   "VT",
+			Name:                 "Turnaround",
+			WorkflowType:         "turnaround",
+			WorkflowFactor:       "",
+			VehicleType:          "car",
+			BranchIdentifier:     "6",
+			ContractorIdentifier: "987654",
+			MovementActivities: []domain.MovementActivity{
+				{
+					Option: "",
+					Type:   "checkin",
+				},
+				{
+					Option: "",
+					Type:   "parking",
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		Alias                 string
+		OptionsIn             []application.Option
+		TieBreakersIn         []application.TieBreaker
+		ExpectedConditionIds  []string
+		ExpectedIsTieResolved bool
+	}{
+		{
+			Alias:                 `No tie breakers leave the tie for manual review`,
+			TieBreakersIn:         nil,
+			ExpectedConditionIds:  nil,
+			ExpectedIsTieResolved: false,
+		},
+		{
+			Alias:                 `Same number of bundles does not resolve the tie`,
+			TieBreakersIn:         []application.TieBreaker{application.TieBreakFewerBundles()},
+			ExpectedConditionIds:  nil,
+			ExpectedIsTieResolved: false,
+		},
+		{
+			Alias:                 `More specific condition wins`,
+			TieBreakersIn:         []application.TieBreaker{application.TieBreakMoreSpecificConditions()},
+			ExpectedConditionIds:  []string{"WF+Opts"},
+			ExpectedIsTieResolved: true,
+		},
+		{
+			Alias:                 `Lowest condition Id wins`,
+			TieBreakersIn:         []application.TieBreaker{application.TieBreakLowestConditionId()},
+			ExpectedConditionIds:  []string{"VT"},
+			ExpectedIsTieResolved: true,
+		},
+		{
+			Alias: `Higher priority condition wins`,
+			TieBreakersIn: []application.TieBreaker{application.TieBreakConditionPriority(func(cond *domain.ContractCondition) int {
+				if cond.Id == "WF+Opts" {
+					return 1
+				}
+				return 0
+			})},
+			ExpectedConditionIds:  []string{"WF+Opts"},
+			ExpectedIsTieResolved: true,
+		},
+		{
+			Alias:                 `Tie breakers are applied in order`,
+			TieBreakersIn:         []application.TieBreaker{application.TieBreakFewerBundles(), application.TieBreakLowestConditionId(), application.TieBreakMoreSpecificConditions()},
+			ExpectedConditionIds:  []string{"VT"},
+			ExpectedIsTieResolved: true,
+		},
+		{
+			Alias:                 `Tie breakers replace previously configured ones`,
+			OptionsIn:             []application.Option{application.WithTieBreakers(application.TieBreakLowestConditionId())},
+			TieBreakersIn:         nil,
+			ExpectedConditionIds:  nil,
+			ExpectedIsTieResolved: false,
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			ctx := context.Background()
+
+			opts := append(append([]application.Option{}, tCase.OptionsIn...), application.WithTieBreakers(tCase.TieBreakersIn...))
+
+			actualResult, err := application.MatchMovements(ctx, movements, conditions, opts...)
+
+			if tCase.ExpectedIsTieResolved {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, application.ErrAmbiguousResult)
+			}
+
+			assert.True(t, actualResult.IsTie)
+			assert.Len(t, actualResult.TiedCombinations, 2)
+			assert.Equal(t, tCase.ExpectedIsTieResolved, actualResult.IsTieResolved)
+
+			actualConditionIds := []string(nil)
+			for _, match := range actualResult.Bundles {
+				actualConditionIds = append(actualConditionIds, match.ContractCondition.Id)
+			}
+			actualUnmatched := actualResult.Unmatched
+
+			assert.Equal(t, tCase.ExpectedConditionIds, actualConditionIds)
+
+			if !tCase.ExpectedIsTieResolved {
+				assert.Equal(t, movements, actualUnmatched)
+				assert.Equal(t, 0, actualResult.Score)
+				return
+			}
+
+			assert.Empty(t, actualUnmatched)
+			assert.Equal(t, 6, actualResult.Score)
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func TestMatchMovements_Rejections(t *testing.T) {
+
+	type rejection struct {
+		MovementId  string
+		ConditionId string
+		Reason      application.RejectionReason
+	}
+
+	movements := []application.Movement{
+		application.Movement{
+			Id:       "1",
+			Type:     "checkin",
+			Option:   "",
+			Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+			Branch:   application.Branch{Id: "6"},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+			User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		},
+		application.Movement{
+			Id:       "2",
+			Type:     "parking",
+			Option:   "",
+			Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+			Branch:   application.Branch{Id: "6"},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+			User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		},
+		application.Movement{
+			Id:       "3",
+			Type:     "parking",
+			Option:   "",
+			Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+			Branch:   application.Branch{Id: "7"},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+			User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		},
+		application.Movement{
+			Id:       "4",
+			Type:     "refuel",
+			Option:   "",
+			Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+			Branch:   application.Branch{Id: "6"},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+			User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		},
+		application.Movement{
+			Id:       "5",
+			Type:     "checkin",
+			Option:   "",
+			Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+			Branch:   application.Branch{Id: "6"},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+			User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "van", Id: "TheVehicleId"},
+		},
+		application.Movement{
+			Id:       "6",
+			Type:     "checkin",
+			Option:   "",
+			Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+			Branch:   application.Branch{Id: "6"},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "express"},
+			User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		},
+		application.Movement{
+			Id:       "7",
+			Type:     "checkin",
+			Option:   "",
+			Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+			Branch:   application.Branch{Id: "6"},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+			User:     application.User{Contractor: func() *string { s := "123456"; return &s }(), Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		},
+		application.Movement{
+			Id:       "8",
+			Type:     "checkin",
+			Option:   "",
+			Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+			Branch:   application.Branch{Id: "6"},
+			Workflow: application.Workflow{Id: "12314654", Type: "transfer", Factor: "standard"},
+			User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		},
+		application.Movement{
+			Id:       "9",
+			Type:     "parking",
+			Option:   "vip",
+			Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+			Branch:   application.Branch{Id: "6"},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+			User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		},
+		application.Movement{
+			Id:       "10",
+			Type:     "checkin",
+			Option:   "",
+			Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+			Branch:   application.Branch{Id: "6"},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+			User:     application.User{Contractor: func() *string { s := "123456"; return &s }(), Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		},
+	}
+
+	conditions := []domain.ContractCondition{
+		domain.ContractCondition{
+			Id:                   "Turnaround",
+			Name:                 "Turnaround",
+			WorkflowType:         "turnaround",
+			WorkflowFactor:       "standard",
+			VehicleType:          "car",
+			BranchIdentifier:     "6",
+			ContractorIdentifier: "987654",
+			MovementActivities: []domain.MovementActivity{
+				{
+					Option: "",
+					Type:   "checkin",
+				},
+				{
+					Option: "",
+					Type:   "parking",
+				},
+			},
+		},
+		domain.ContractCondition{
+			Id:                   "VipTurnaround",
+			Name:                 "Turnaround",
+			WorkflowType:         "turnaround",
+			WorkflowFactor:       "standard",
+			VehicleType:          "car",
+			BranchIdentifier:     "6",
+			ContractorIdentifier: "987654",
+			MovementActivities: []domain.MovementActivity{
+				{
+					Option: "",
+					Type:   "checkin",
+				},
+				{
+					Option: "premium",
+					Type:   "parking",
+				},
+			},
+		},
+		domain.ContractCondition{
+			Id:                   "Checkin",
+			Name:                 "Checkin",
+			WorkflowType:         "turnaround",
+			WorkflowFactor:       "standard",
+			VehicleType:          "car",
+			BranchIdentifier:     "6",
+			ContractorIdentifier: "987654",
+			MovementActivities: []domain.MovementActivity{
+				{
+					Option: "",
+					Type:   "checkin",
+				},
+			},
+		},
+		domain.ContractCondition{
+			Id:                   "Capped",
+			Name:                 "Capped",
+			WorkflowType:         "turnaround",
+			WorkflowFactor:       "standard",
+			VehicleType:          "car",
+			BranchIdentifier:     "6",
+			ContractorIdentifier: "123456",
+			MaxInstances:         1,
+			MovementActivities: []domain.MovementActivity{
+				{
+					Option: "",
+					Type:   "checkin",
+				},
+			},
+		},
+	}
+
+	expectedRejections := []rejection{
+		{MovementId: "3", ConditionId: "Turnaround", Reason: application.RejectionReasonBranchMismatch},
+		{MovementId: "4", ConditionId: "Turnaround", Reason: application.RejectionReasonMovementActivityTypeMismatch},
+		{MovementId: "5", ConditionId: "Turnaround", Reason: application.RejectionReasonVehicleTypeMismatch},
+		{MovementId: "6", ConditionId: "Turnaround", Reason: application.RejectionReasonWorkflowFactorMismatch},
+		{MovementId: "10", ConditionId: "Turnaround", Reason: application.RejectionReasonContractorMismatch},
+		{MovementId: "8", ConditionId: "Turnaround", Reason: application.RejectionReasonWorkflowTypeMismatch},
+		{MovementId: "9", ConditionId: "Turnaround", Reason: application.RejectionReasonInsufficientMovements},
+		{MovementId: "9", ConditionId: "VipTurnaround", Reason: application.RejectionReasonOptionMismatch},
+		{MovementId: "3", ConditionId: "Checkin", Reason: application.RejectionReasonBranchMismatch},
+		{MovementId: "5", ConditionId: "Checkin", Reason: application.RejectionReasonVehicleTypeMismatch},
+		{MovementId: "9", ConditionId: "Checkin", Reason: application.RejectionReasonMovementActivityTypeMismatch},
+		{MovementId: "10", ConditionId: "Capped", Reason: application.RejectionReasonMaxInstances},
+	}
+
+	ctx := context.Background()
+
+	actualResult, err := application.MatchMovements(ctx, movements, conditions)
+
+	assert.NoError(t, err)
+
+	if assert.Len(t, actualResult.Bundles, 2) {
+		assert.Equal(t, "Turnaround", actualResult.Bundles[0].ContractCondition.Id)
+		assert.Equal(t, movements[:2], actualResult.Bundles[0].Movements)
+		assert.Equal(t, "Capped", actualResult.Bundles[1].ContractCondition.Id)
+		assert.Equal(t, movements[6:7], actualResult.Bundles[1].Movements)
+	}
+	expectedUnmatched := append(append([]application.Movement{}, movements[2:6]...), movements[7:]...)
+	assert.Equal(t, expectedUnmatched, actualResult.Unmatched)
+
+	// Every unmatched movement is explained for every contract condition
+	assert.Len(t, actualResult.Rejections, len(expectedUnmatched)*len(conditions))
+
+	actualRejections := []rejection{}
+	for _, r := range actualResult.Rejections {
+		actualRejections = append(actualRejections, rejection{MovementId: r.Movement.Id, ConditionId: r.ContractCondition.Id, Reason: r.Reason})
+	}
+
+	assert.Subset(t, actualRejections, expectedRejections)
+}
+
+// cancellingScorer cancels the context as soon as it is asked to score the movement for the contract condition with cancelOnConditionId.
+type cancellingScorer struct {
+	application.Scorer
+	cancel              context.CancelFunc
+	cancelOnConditionId string
+}
+
+func (s *cancellingScorer) Score(cond *domain.ContractCondition, ccma domain.MovementActivity, mvmt application.Movement) (application.ActivityScore, bool) {
+	if cond.Id == s.cancelOnConditionId {
+		s.cancel()
+	}
+	return s.Scorer.Score(cond, ccma, mvmt)
+}
+
+func TestMatchMovements_OnContextDone(t *testing.T) {
+
+	movements := []application.Movement{
+		application.Movement{
+			Id:       "132456",
+			Type:     "checkin",
+			Option:   "",
+			Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+			Branch:   application.Branch{Id: "6"},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+			User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		},
+		application.Movement{
+			Id:       "132457",
+			Type:     "parking",
+			Option:   "",
+			Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+			Branch:   application.Branch{Id: "6"},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+			User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		},
+		application.Movement{
+			Id:       "132458",
+			Type:     "checkin",
+			Option:   "",
+			Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+			Branch:   application.Branch{Id: "6"},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+			User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		},
+		application.Movement{
+			Id:       "132459",
+			Type:     "parking",
+			Option:   "",
+			Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+			Branch:   application.Branch{Id: "6"},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+			User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		},
+	}
+
+	conditions := []domain.ContractCondition{
+		domain.ContractCondition{
+			Id:                   "First",
+			Name:                 "Turnaround",
+			WorkflowType:         "turnaround",
+			WorkflowFactor:       "standard",
+			VehicleType:          "car",
+			BranchIdentifier:     "6",
+			ContractorIdentifier: "987654",
+			MaxInstances:         1,
+			MovementActivities: []domain.MovementActivity{
+				{
+					Option: "",
+					Type:   "checkin",
+				},
+				{
+					Option: "",
+					Type:   "parking",
+				},
+			},
+		},
+		domain.ContractCondition{
+			Id:                   "Second",
+			Name:                 "Turnaround",
+			WorkflowType:         "turnaround",
+			WorkflowFactor:       "standard",
+			VehicleType:          "car",
+			BranchIdentifier:     "6",
+			ContractorIdentifier: "987654",
+			MaxInstances:         1,
+			MovementActivities: []domain.MovementActivity{
+				{
+					Option: "",
+					Type:   "checkin",
+				},
+				{
+					Option: "",
+					Type:   "parking",
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		Alias                string
+		ContextIn            func() (context.Context, []application.Option)
+		ExpectedErr          error
+		ExpectedConditionIds []string
+	}{
+		{
+			Alias: `Not interrupted search matches both conditions`,
+			ContextIn: func() (context.Context, []application.Option) {
+				return context.Background(), nil
+			},
+			ExpectedErr:          nil,
+			ExpectedConditionIds: []string{"First", "Second"},
+		},
+		{
+			Alias: `Cancelled before start`,
+			ContextIn: func() (context.Context, []application.Option) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, nil
+			},
+			ExpectedErr:          context.Canceled,
+			ExpectedConditionIds: nil,
+		},
+		{
+			Alias: `Deadline exceeded before start`,
+			ContextIn: func() (context.Context, []application.Option) {
+				ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+				// The deadline has already been exceeded, so cancelling keeps ctx.Err() as is.
+				cancel()
+				return ctx, nil
+			},
+			ExpectedErr:          context.DeadlineExceeded,
+			ExpectedConditionIds: nil,
+		},
+		{
+			Alias: `Cancelled in the middle returns the best found so far`,
+			ContextIn: func() (context.Context, []application.Option) {
+				ctx, cancel := context.WithCancel(context.Background())
+				scorer := &cancellingScorer{
+					Scorer:              application.NewWeightedScorer(application.DefaultScoreWeights()),
+					cancel:              cancel,
+					cancelOnConditionId: "Second",
+				}
+				return ctx, []application.Option{application.WithScorer(scorer)}
+			},
+			ExpectedErr:          context.Canceled,
+			ExpectedConditionIds: []string{"First"},
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			ctx, opts := tCase.ContextIn()
+
+			actualResult, err := application.MatchMovements(ctx, movements, conditions, opts...)
+
+			if tCase.ExpectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, application.ErrPartialResult)
+				assert.ErrorIs(t, err, tCase.ExpectedErr)
+			}
+
+			actualConditionIds := []string(nil)
+			actualMatchedCount := 0
+			for _, match := range actualResult.Bundles {
+				actualConditionIds = append(actualConditionIds, match.ContractCondition.Id)
+				actualMatchedCount += len(match.Movements)
+			}
+
+			assert.ElementsMatch(t, tCase.ExpectedConditionIds, actualConditionIds)
+			assert.Len(t, actualResult.Unmatched, len(movements)-actualMatchedCount)
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func TestMatchMovements_RejectionsOnContextDone(t *testing.T) {
+
+	date := time.Date(2018, 01, 31, 16, 59, 59, 0, time.UTC)
+
+	movements := []application.Movement{
+		newMovement("1", "checkin", func(mvmt *application.Movement) { mvmt.Vehicle.Id = "A"; mvmt.Date = date }),
+		newMovement("2", "parking", func(mvmt *application.Movement) { mvmt.Vehicle.Id = "B"; mvmt.Date = date.Add(-2 * time.Hour) }),
+	}
+
+	conditions := []domain.ContractCondition{
+		newCondition("TimeWindow", []string{"checkin", "parking"}, func(cond *domain.ContractCondition) { cond.MaxSpan = time.Hour }),
+		newCondition("Coherent", []string{"checkin", "parking"}, func(cond *domain.ContractCondition) {
+			cond.CoherentAttributes = []string{domain.CoherentAttribute_VehicleId}
+		}),
+		newCondition("Ordered", []string{"checkin", "parking"}, func(cond *domain.ContractCondition) { cond.Ordered = true }),
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testCases := []struct {
+		Alias           string
+		CtxIn           context.Context
+		ConditionsIn    []domain.ContractCondition
+		OptionsIn       []application.Option
+		ExpectedErr     error
+		ExpectedReasons map[string]application.RejectionReason
+	}{
+		{
+			Alias: `Bundle constraints are explained after the search is over`,
+			CtxIn: context.Background(),
+			ExpectedReasons: map[string]application.RejectionReason{
+				"TimeWindow/1": application.RejectionReasonTimeWindow,
+				"TimeWindow/2": application.RejectionReasonTimeWindow,
+				"Coherent/1":   application.RejectionReasonIncoherent,
+				"Coherent/2":   application.RejectionReasonIncoherent,
+				"Ordered/1":    application.RejectionReasonOutOfSequence,
+				"Ordered/2":    application.RejectionReasonOutOfSequence,
+			},
+		},
+		{
+			Alias:       `Bundle constraints are left unexplained once ctx is done`,
+			CtxIn:       cancelled,
+			ExpectedErr: context.Canceled,
+			ExpectedReasons: map[string]application.RejectionReason{
+				"TimeWindow/1": application.RejectionReasonUnexplained,
+				"TimeWindow/2": application.RejectionReasonUnexplained,
+				"Coherent/1":   application.RejectionReasonUnexplained,
+				"Coherent/2":   application.RejectionReasonUnexplained,
+				"Ordered/1":    application.RejectionReasonUnexplained,
+				"Ordered/2":    application.RejectionReasonUnexplained,
+			},
+		},
+		{
+			Alias:       `Bundle constraints are left unexplained once the search budget is exceeded`,
+			CtxIn:       context.Background(),
+			OptionsIn:   []application.Option{application.WithSearchBudget(1)},
+			ExpectedErr: application.ErrSearchBudgetExceeded,
+			ExpectedReasons: map[string]application.RejectionReason{
+				"TimeWindow/1": application.RejectionReasonUnexplained,
+				"TimeWindow/2": application.RejectionReasonUnexplained,
+				"Coherent/1":   application.RejectionReasonUnexplained,
+				"Coherent/2":   application.RejectionReasonUnexplained,
+				"Ordered/1":    application.RejectionReasonUnexplained,
+				"Ordered/2":    application.RejectionReasonUnexplained,
+			},
+		},
+		{
+			Alias:        `Fitting movements are not told to be insufficient once ctx is done`,
+			CtxIn:        cancelled,
+			ConditionsIn: []domain.ContractCondition{newCondition("Single", []string{"checkin"})},
+			ExpectedErr:  context.Canceled,
+			ExpectedReasons: map[string]application.RejectionReason{
+				"Single/1": application.RejectionReasonUnexplained,
+				"Single/2": application.RejectionReasonUnexplained,
+			},
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			conds := conditions
+			if tCase.ConditionsIn != nil {
+				conds = tCase.ConditionsIn
+			}
+
+			actualResult, err := application.MatchMovements(tCase.CtxIn, movements, conds, tCase.OptionsIn...)
+
+			if tCase.ExpectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, application.ErrPartialResult)
+				assert.ErrorIs(t, err, tCase.ExpectedErr)
+			}
+
+			actualReasons := map[string]application.RejectionReason{}
+			for _, rejection := range actualResult.Rejections {
+				actualReasons[rejection.ContractCondition.Id+"/"+rejection.Movement.Id] = rejection.Reason
+			}
+			assert.Equal(t, tCase.ExpectedReasons, actualReasons)
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func TestMatchMovements_RejectionsOnDeadline(t *testing.T) {
+
+	// Checkins and parkings of different vehicles fit every condition, but never match together,
+	// so the explanation of bundle constraints could not go through all assignments in time, whether the search is interrupted or not.
+	const pairsCount, conditionsCount = 500, 300
+	const timeout = 200 * time.Millisecond
+
+	movements := []application.Movement{}
+	for pairNo := 0; pairNo < pairsCount; pairNo++ {
+		for movementNo, movementType := range []string{"checkin", "parking"} {
+			movements = append(movements, newMovement(strconv.Itoa(pairNo)+"-"+movementType, movementType, func(mvmt *application.Movement) {
+				mvmt.Vehicle.Id = strconv.Itoa(2*pairNo + movementNo)
+			}))
+		}
+	}
+
+	conditions := []domain.ContractCondition{}
+	for condNo := 0; condNo < conditionsCount; condNo++ {
+		conditions = append(conditions, newCondition(strconv.Itoa(condNo), []string{"checkin", "parking"}, func(cond *domain.ContractCondition) {
+			cond.CoherentAttributes = []string{domain.CoherentAttribute_VehicleId}
+		}))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	actualResult, err := application.MatchMovements(ctx, movements, conditions)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	}
+	assert.Less(t, elapsed, 4*timeout)
+	assert.Len(t, actualResult.Rejections, len(movements)*len(conditions))
+
+	actualReasons := map[application.RejectionReason]bool{}
+	for _, rejection := range actualResult.Rejections {
+		actualReasons[rejection.Reason] = true
+	}
+	assert.True(t, actualReasons[application.RejectionReasonUnexplained])
+	delete(actualReasons, application.RejectionReasonIncoherent)
+	delete(actualReasons, application.RejectionReasonUnexplained)
+	assert.Empty(t, actualReasons)
+}
+
+func TestMatchMovements_Errors(t *testing.T) {
+
+	inEUR := func(cond *domain.ContractCondition) { cond.Price = domain.PriceModel{Currency: "EUR", Flat: "1.00"} }
+	inUSD := func(cond *domain.ContractCondition) { cond.Price = domain.PriceModel{Currency: "USD", Flat: "1.00"} }
+
+	testCases := []struct {
+		Alias        string
+		MovementsIn  []application.Movement
+		ConditionsIn []domain.ContractCondition
+		OptionsIn    []application.Option
+		ExpectedErrs []error
+	}{
+		{
+			Alias: `Valid input`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "132456",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "132457",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Turnaround",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			OptionsIn:    nil,
+			ExpectedErrs: nil,
+		},
+		{
+			Alias: `Condition without movement activities`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "132456",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "132457",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Turnaround",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+				domain.ContractCondition{
+					Id:                   "Empty",
+					Name:                 "Nothing",
+					WorkflowType:         "turnaround",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+				},
+			},
+			OptionsIn:    nil,
+			ExpectedErrs: []error{application.ErrInvalidCondition},
+		},
+		{
+			Alias: `Duplicate movement Id`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "132456",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "132456",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Turnaround",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			OptionsIn:    nil,
+			ExpectedErrs: []error{application.ErrDuplicateMovement},
+		},
+		{
+			Alias: `Malformed price`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "132456",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "132457",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Turnaround",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					Price:                domain.PriceModel{Currency: "EUR", Flat: "ten"},
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			OptionsIn:    nil,
+			ExpectedErrs: []error{application.ErrInvalidCondition, application.ErrInvalidPrice},
+		},
+		{
+			Alias: `Empty contractor instead of nil`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "132456",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := ""; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "132457",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Turnaround",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			OptionsIn:    nil,
+			ExpectedErrs: []error{application.ErrInvalidMovement},
+		},
+		{
+			Alias: `Search budget exceeded`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "132456",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "132457",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Turnaround",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			OptionsIn:    []application.Option{application.WithSearchBudget(1)},
+			ExpectedErrs: []error{application.ErrPartialResult, application.ErrSearchBudgetExceeded},
+		},
+		{
+			Alias:        `Mixed currencies without price objectives`,
+			MovementsIn:  []application.Movement{newMovement("132456", "checkin"), newMovement("132457", "parking")},
+			ConditionsIn: []domain.ContractCondition{newCondition("Turnaround", []string{"checkin", "parking"}, inEUR), newCondition("Wash", []string{"wash"}, inUSD)},
+			OptionsIn:    []application.Option{application.WithObjectives(application.ObjectiveMatchedMovements())},
+			ExpectedErrs: nil,
+		},
+		{
+			Alias:        `Mixed currencies along with price objective`,
+			MovementsIn:  []application.Movement{newMovement("132456", "checkin"), newMovement("132457", "parking")},
+			ConditionsIn: []domain.ContractCondition{newCondition("Turnaround", []string{"checkin", "parking"}, inEUR), newCondition("Wash", []string{"wash"}, inUSD)},
+			OptionsIn:    []application.Option{application.WithObjectives(application.ObjectiveRevenue())},
+			ExpectedErrs: []error{application.ErrInvalidCondition, application.ErrInvalidPrice},
+		},
+		{
+			Alias:       `Malformed vehicle type pattern`,
+			MovementsIn: []application.Movement{newMovement("132456", "checkin"), newMovement("132457", "parking")},
+			ConditionsIn: []domain.ContractCondition{newCondition("Turnaround", []string{"checkin", "parking"}, func(cond *domain.ContractCondition) {
+				cond.VehicleType = "["
+			})},
+			ExpectedErrs: []error{application.ErrInvalidCondition},
+		},
+		{
+			Alias:       `Malformed option pattern`,
+			MovementsIn: []application.Movement{newMovement("132456", "checkin"), newMovement("132457", "parking")},
+			ConditionsIn: []domain.ContractCondition{newCondition("Turnaround", []string{"checkin", "parking"}, func(cond *domain.ContractCondition) {
+				cond.MovementActivities[0].Option = "[a"
+			})},
+			ExpectedErrs: []error{application.ErrInvalidCondition},
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			ctx := context.Background()
+
+			actualResult, err := application.MatchMovements(ctx, tCase.MovementsIn, tCase.ConditionsIn, tCase.OptionsIn...)
+
+			if len(tCase.ExpectedErrs) == 0 {
+				assert.NoError(t, err)
+				assert.Len(t, actualResult.Bundles, 1)
+				return
+			}
+
+			for _, expectedErr := range tCase.ExpectedErrs {
+				assert.ErrorIs(t, err, expectedErr)
+			}
+			assert.Empty(t, actualResult.Bundles)
+			assert.Equal(t, tCase.MovementsIn, actualResult.Unmatched)
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func TestMatchMovementsToBundleContractConditions_SingleActivity(t *testing.T) {
+
+	testCases := []struct {
+		Alias           string
+		MovementsIn     []application.Movement
+		ConditionsIn    []domain.ContractCondition
+		ExpectedMatches []application.Match
+	}{
+		{
+			Alias: `Bundle leftovers are matched to the best single activity condition`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "132456",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+
+				application.Movement{
+					Id:       "132457",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+
+				application.Movement{
+					Id:       "132458",
+					Type:     "refuel",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+
+				application.Movement{
+					Id:       "132459",
+					Type:     "parking",
+					Option:   "vip",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Turnaround",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+				domain.ContractCondition{
+					Id:                   "Refuel",
+					Name:                 "Refuel",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "refuel",
+						},
+					},
+				},
+				domain.ContractCondition{
+					Id:                   "Parking",
+					Name:                 "Parking",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+				domain.ContractCondition{
+					Id:                   "VipParking",
+					Name:                 "Vip parking",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "vip",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			ExpectedMatches: []application.Match{
+				application.Match{
+					Movements: []application.Movement{
+						application.Movement{
+							Id:       "132456",
+							Type:     "checkin",
+							Option:   "",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+						application.Movement{
+							Id:       "132457",
+							Type:     "parking",
+							Option:   "",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+					},
+					ContractCondition: &domain.ContractCondition{
+						Id:                   "Turnaround",
+						Name:                 "Turnaround",
+						WorkflowType:         "turnaround",
+						WorkflowFactor:       "standard",
+						VehicleType:          "car",
+						BranchIdentifier:     "6",
+						ContractorIdentifier: "987654",
+						MovementActivities: []domain.MovementActivity{
+							{
+								Option: "",
+								Type:   "checkin",
+							},
+							{
+								Option: "",
+								Type:   "parking",
+							},
+						},
+					},
+					Score: 12,
+				},
+				application.Match{
+					Movements: []application.Movement{
+						application.Movement{
+							Id:       "132458",
+							Type:     "refuel",
+							Option:   "",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+					},
+					ContractCondition: &domain.ContractCondition{
+						Id:                   "Refuel",
+						Name:                 "Refuel",
+						WorkflowType:         "turnaround",
+						WorkflowFactor:       "standard",
+						VehicleType:          "car",
+						BranchIdentifier:     "6",
+						ContractorIdentifier: "987654",
+						MovementActivities: []domain.MovementActivity{
+							{
+								Option: "",
+								Type:   "refuel",
+							},
+						},
+					},
+					Score: 6,
+				},
+				application.Match{
+					Movements: []application.Movement{
+						application.Movement{
+							Id:       "132459",
+							Type:     "parking",
+							Option:   "vip",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+					},
+					ContractCondition: &domain.ContractCondition{
+						Id:                   "VipParking",
+						Name:                 "Vip parking",
+						WorkflowType:         "turnaround",
+						WorkflowFactor:       "standard",
+						VehicleType:          "car",
+						BranchIdentifier:     "6",
+						ContractorIdentifier: "987654",
+						MovementActivities: []domain.MovementActivity{
+							{
+								Option: "vip",
+								Type:   "parking",
+							},
+						},
+					},
+					Score: 6,
+				},
+			},
+		},
+		{
+			Alias: `Single activity conditions only`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "132456",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+
+				application.Movement{
+					Id:       "132457",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+
+				application.Movement{
+					Id:       "132458",
+					Type:     "refuel",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Checkin",
+					Name:                 "Checkin",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+					},
+				},
+				domain.ContractCondition{
+					Id:                   "Parking",
+					Name:                 "Parking",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			ExpectedMatches: []application.Match{
+				application.Match{
+					Movements: []application.Movement{
+						application.Movement{
+							Id:       "132456",
+							Type:     "checkin",
+							Option:   "",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+					},
+					ContractCondition: &domain.ContractCondition{
+						Id:                   "Checkin",
+						Name:                 "Checkin",
+						WorkflowType:         "turnaround",
+						WorkflowFactor:       "standard",
+						VehicleType:          "car",
+						BranchIdentifier:     "6",
+						ContractorIdentifier: "987654",
+						MovementActivities: []domain.MovementActivity{
+							{
+								Option: "",
+								Type:   "checkin",
+							},
+						},
+					},
+					Score: 6,
+				},
+				application.Match{
+					Movements: []application.Movement{
+						application.Movement{
+							Id:       "132457",
+							Type:     "parking",
+							Option:   "",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+					},
+					ContractCondition: &domain.ContractCondition{
+						Id:                   "Parking",
+						Name:                 "Parking",
+						WorkflowType:         "turnaround",
+						WorkflowFactor:       "standard",
+						VehicleType:          "car",
+						BranchIdentifier:     "6",
+						ContractorIdentifier: "987654",
+						MovementActivities: []domain.MovementActivity{
+							{
+								Option: "",
+								Type:   "parking",
+							},
+						},
+					},
+					Score: 6,
+				},
+				application.Match{
+					Movements: []application.Movement{
+						application.Movement{
+							Id:       "132458",
+							Type:     "refuel",
+							Option:   "",
+							Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+							Branch:   application.Branch{Id: "6"},
+							Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+							User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+							Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+						},
+					},
+					ContractCondition: nil,
+					Score:             0,
+				},
+			},
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			ctx := context.Background()
+
+			actualMatches := application.MatchMovementsToBundleContractConditions(ctx, tCase.MovementsIn, tCase.ConditionsIn)
+
+			assert.ElementsMatch(t, tCase.ExpectedMatches, actualMatches)
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+
+}
+
+func TestMatchMovements_ValidityPeriod(t *testing.T) {
+
+	conditions := []domain.ContractCondition{
+		domain.ContractCondition{
+			Id:                   "Turnaround",
+			Name:                 "Turnaround",
+			WorkflowType:         "turnaround",
+			WorkflowFactor:       "standard",
+			VehicleType:          "car",
+			BranchIdentifier:     "6",
+			ContractorIdentifier: "987654",
+			Version:              2018,
+			ValidFrom:            time.Date(2018, 01, 01, 0, 0, 0, 0, time.UTC),
+			ValidTo:              time.Date(2019, 01, 01, 0, 0, 0, 0, time.UTC),
+			MovementActivities: []domain.MovementActivity{
+				{
+					Option: "",
+					Type:   "checkin",
+				},
+				{
+					Option: "",
+					Type:   "parking",
+				},
+			},
+		},
+		domain.ContractCondition{
+			Id:                   "Turnaround",
+			Name:                 "Turnaround",
+			WorkflowType:         "turnaround",
+			WorkflowFactor:       "standard",
+			VehicleType:          "car",
+			BranchIdentifier:     "6",
+			ContractorIdentifier: "987654",
+			Version:              2019,
+			ValidFrom:            time.Date(2019, 01, 01, 0, 0, 0, 0, time.UTC),
+			ValidTo:              time.Date(2020, 01, 01, 0, 0, 0, 0, time.UTC),
+			MovementActivities: []domain.MovementActivity{
+				{
+					Option: "",
+					Type:   "checkin",
+				},
+				{
+					Option: "",
+					Type:   "parking",
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		Alias           string
+		MovementsIn     []application.Movement
+		OptionsIn       []application.Option
+		ExpectedVersion int
+	}{
+		{
+			Alias: `Movements within the first version`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "132456",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 06, 15, 12, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "132457",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 06, 15, 12, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			OptionsIn:       nil,
+			ExpectedVersion: 2018,
+		},
+		{
+			Alias: `Movements within the second version`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "132456",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2019, 06, 15, 12, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "132457",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2019, 06, 15, 12, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			OptionsIn:       nil,
+			ExpectedVersion: 2019,
+		},
+		{
+			Alias: `Movements at the end of year in UTC`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "132456",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 12, 31, 23, 30, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "132457",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 12, 31, 23, 30, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			OptionsIn:       nil,
+			ExpectedVersion: 2018,
+		},
+		{
+			Alias: `Movements at the end of year in UTC are already in the next year of the branch`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "132456",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 12, 31, 23, 30, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "132457",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 12, 31, 23, 30, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			OptionsIn:       []application.Option{application.WithBranchLocations(map[string]*time.Location{"6": time.FixedZone("EET", 2*60*60)})},
+			ExpectedVersion: 2019,
+		},
+		{
+			Alias: `Movements out of any version`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "132456",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2020, 01, 01, 0, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "132457",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2020, 01, 01, 0, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			OptionsIn:       nil,
+			ExpectedVersion: 0,
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			ctx := context.Background()
+
+			actualResult, err := application.MatchMovements(ctx, tCase.MovementsIn, conditions, tCase.OptionsIn...)
+
+			assert.NoError(t, err)
+
+			if tCase.ExpectedVersion == 0 {
+				assert.Empty(t, actualResult.Bundles)
+				for _, rejection := range actualResult.Rejections {
+					assert.Equal(t, application.RejectionReasonOutOfValidity, rejection.Reason)
+				}
+				return
+			}
+
+			if assert.Len(t, actualResult.Bundles, 1) {
+				assert.Equal(t, tCase.ExpectedVersion, actualResult.Bundles[0].ContractCondition.Version)
+			}
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func TestMatchMovements_BundleConstraints(t *testing.T) {
+
+	testCases := []struct {
+		Alias           string
+		MovementsIn     []application.Movement
+		ConditionsIn    []domain.ContractCondition
+		ExpectedBundled []string
+		ExpectedReasons map[string]application.RejectionReason
+	}{
+		{
+			Alias: `Movements far apart are not bundled`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 01, 10, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "2",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 03, 01, 10, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "3",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 03, 01, 9, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Turnaround",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MaxSpan:              2 * time.Hour,
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			ExpectedBundled: []string{"3", "2"},
+			ExpectedReasons: map[string]application.RejectionReason{"1": application.RejectionReasonTimeWindow},
+		},
+		{
+			Alias: `Movements too close to each other are not bundled`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 01, 10, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "2",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 01, 10, 10, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Turnaround",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MinGap:               30 * time.Minute,
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			ExpectedBundled: nil,
+			ExpectedReasons: map[string]application.RejectionReason{"1": application.RejectionReasonTimeWindow, "2": application.RejectionReasonTimeWindow},
+		},
+		{
+			Alias: `Movements within the time window are bundled`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 01, 10, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "2",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 01, 11, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Turnaround",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MaxSpan:              2 * time.Hour,
+					MinGap:               30 * time.Minute,
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			ExpectedBundled: []string{"1", "2"},
+			ExpectedReasons: map[string]application.RejectionReason{},
+		},
+		{
+			Alias: `Movements of different vehicles are not bundled`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "A"},
+				},
+				application.Movement{
+					Id:       "2",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "B"},
+				},
+				application.Movement{
+					Id:       "3",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "A"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Turnaround",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					CoherentAttributes:   []string{domain.CoherentAttribute_VehicleId},
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			ExpectedBundled: []string{"1", "3"},
+			ExpectedReasons: map[string]application.RejectionReason{"2": application.RejectionReasonIncoherent},
+		},
+		{
+			Alias: `Movements of different workflows and users are not bundled`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "W1", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "2",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "W2", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "3",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "W1", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "OtherUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Turnaround",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					CoherentAttributes:   []string{domain.CoherentAttribute_WorkflowId, domain.CoherentAttribute_UserId},
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			ExpectedBundled: nil,
+			ExpectedReasons: map[string]application.RejectionReason{"1": application.RejectionReasonIncoherent, "2": application.RejectionReasonIncoherent, "3": application.RejectionReasonIncoherent},
+		},
+		{
+			Alias: `Ordered bundle picks movements in sequence`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkout",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 9, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "2",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 10, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "3",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 11, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "4",
+					Type:     "checkout",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 12, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Stay",
+					Name:                 "Stay",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					Ordered:              true,
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+						{
+							Option: "",
+							Type:   "checkout",
+						},
+					},
+				},
+			},
+			ExpectedBundled: []string{"2", "3", "4"},
+			ExpectedReasons: map[string]application.RejectionReason{"1": application.RejectionReasonOutOfSequence},
+		},
+		{
+			Alias: `Equal timestamps are out of sequence without tolerance`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 10, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "2",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 10, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "3",
+					Type:     "checkout",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 11, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Stay",
+					Name:                 "Stay",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					Ordered:              true,
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+						{
+							Option: "",
+							Type:   "checkout",
+						},
+					},
+				},
+			},
+			ExpectedBundled: nil,
+			ExpectedReasons: map[string]application.RejectionReason{"1": application.RejectionReasonOutOfSequence, "2": application.RejectionReasonOutOfSequence, "3": application.RejectionReasonOutOfSequence},
+		},
+		{
+			Alias: `Equal timestamps are in sequence with tolerance`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 10, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "2",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 10, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "3",
+					Type:     "checkout",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 11, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Stay",
+					Name:                 "Stay",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					Ordered:              true,
+					OrderTolerance:       time.Minute,
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+						{
+							Option: "",
+							Type:   "checkout",
+						},
+					},
+				},
+			},
+			ExpectedBundled: []string{"1", "2", "3"},
+			ExpectedReasons: map[string]application.RejectionReason{},
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			ctx := context.Background()
+
+			actualResult, err := application.MatchMovements(ctx, tCase.MovementsIn, tCase.ConditionsIn)
+
+			assert.NoError(t, err)
+
+			actualBundled := []string(nil)
+			for _, match := range actualResult.Bundles {
+				for _, mvmt := range match.Movements {
+					actualBundled = append(actualBundled, mvmt.Id)
+				}
+			}
+			assert.Equal(t, tCase.ExpectedBundled, actualBundled)
+
+			actualReasons := map[string]application.RejectionReason{}
+			for _, rejection := range actualResult.Rejections {
+				actualReasons[rejection.Movement.Id] = rejection.Reason
+			}
+			assert.Equal(t, tCase.ExpectedReasons, actualReasons)
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func TestMatchMovements_ActivityCardinality(t *testing.T) {
+
+	testCases := []struct {
+		Alias             string
+		MovementsIn       []application.Movement
+		ConditionsIn      []domain.ContractCondition
+		ExpectedBundles   [][]string
+		ExpectedUnmatched []string
+	}{
+		{
+			Alias: `Repeated activity takes up to Max movements`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 9, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "2",
+					Type:     "wash",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 10, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "3",
+					Type:     "wash",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 11, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "4",
+					Type:     "wash",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 12, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "5",
+					Type:     "wash",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 13, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Wash",
+					Name:                 "Wash",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Type: "checkin",
+						},
+						{
+							Type: "wash",
+							Min:  1,
+							Max:  3,
+						},
+						{
+							Type:     "parking",
+							Optional: true,
+						},
+					},
+				},
+			},
+			ExpectedBundles:   [][]string{{"1", "2", "3", "4"}},
+			ExpectedUnmatched: []string{"5"},
+		},
+		{
+			Alias: `Optional activity is matched if there is a fitting movement`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 9, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "2",
+					Type:     "wash",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 10, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "3",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 11, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Wash",
+					Name:                 "Wash",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Type: "checkin",
+						},
+						{
+							Type: "wash",
+							Min:  1,
+							Max:  3,
+						},
+						{
+							Type:     "parking",
+							Optional: true,
+						},
+					},
+				},
+			},
+			ExpectedBundles:   [][]string{{"1", "2", "3"}},
+			ExpectedUnmatched: nil,
+		},
+		{
+			Alias: `Optional activity is not needed`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 9, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "2",
+					Type:     "wash",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 10, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Wash",
+					Name:                 "Wash",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Type: "checkin",
+						},
+						{
+							Type: "wash",
+							Min:  1,
+							Max:  3,
+						},
+						{
+							Type:     "parking",
+							Optional: true,
+						},
+					},
+				},
+			},
+			ExpectedBundles:   [][]string{{"1", "2"}},
+			ExpectedUnmatched: nil,
+		},
+		{
+			Alias: `Required repeated activity needs at least Min movements`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 9, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "2",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 10, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Wash",
+					Name:                 "Wash",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Type: "checkin",
+						},
+						{
+							Type: "wash",
+							Min:  1,
+							Max:  3,
+						},
+						{
+							Type:     "parking",
+							Optional: true,
+						},
+					},
+				},
+			},
+			ExpectedBundles:   nil,
+			ExpectedUnmatched: []string{"1", "2"},
+		},
+		{
+			Alias: `Single activity with Min count is a bundle`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "wash",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 9, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "2",
+					Type:     "wash",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 10, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "3",
+					Type:     "wash",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 11, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "DoubleWash",
+					Name:                 "DoubleWash",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Type: "wash",
+							Min:  2,
+						},
+					},
+				},
+			},
+			ExpectedBundles:   [][]string{{"1", "2"}},
+			ExpectedUnmatched: []string{"3"},
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			ctx := context.Background()
+
+			actualResult, err := application.MatchMovements(ctx, tCase.MovementsIn, tCase.ConditionsIn)
+
+			assert.NoError(t, err)
+
+			actualBundles := [][]string(nil)
+			for _, match := range actualResult.Bundles {
+				bundle := []string{}
+				for _, mvmt := range match.Movements {
+					bundle = append(bundle, mvmt.Id)
+				}
+				actualBundles = append(actualBundles, bundle)
+			}
+			assert.Equal(t, tCase.ExpectedBundles, actualBundles)
+
+			actualUnmatched := []string(nil)
+			for _, mvmt := range actualResult.Unmatched {
+				actualUnmatched = append(actualUnmatched, mvmt.Id)
+			}
+			assert.Equal(t, tCase.ExpectedUnmatched, actualUnmatched)
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func TestMatchMovements_AttributePatterns(t *testing.T) {
+
+	taxonomy := application.VehicleTaxonomy{"van": "light-commercial", "truck": "heavy-commercial", "light-commercial": "any", "heavy-commercial": "any"}
+
+	testCases := []struct {
+		Alias               string
+		MovementsIn         []application.Movement
+		ConditionsIn        []domain.ContractCondition
+		OptionsIn           []application.Option
+		ExpectedConditionId string
+		ExpectedScore       int
+	}{
+		{
+			Alias: `Exact value beats set member`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "express",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "van", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Set",
+					Name:                 "Set",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car|van",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+					},
+				},
+				domain.ContractCondition{
+					Id:                   "Exact",
+					Name:                 "Exact",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "van",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+					},
+				},
+			},
+			ExpectedConditionId: "Exact",
+			ExpectedScore:       5,
+		},
+		{
+			Alias: `Set member beats wildcard`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "express",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "van", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Wildcard",
+					Name:                 "Wildcard",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "*an",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+					},
+				},
+				domain.ContractCondition{
+					Id:                   "Set",
+					Name:                 "Set",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car|van",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+					},
+				},
+			},
+			ExpectedConditionId: "Set",
+			ExpectedScore:       4,
+		},
+		{
+			Alias: `Wildcard beats fallback`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "express",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "van", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Fallback",
+					Name:                 "Fallback",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+					},
+				},
+				domain.ContractCondition{
+					Id:                   "Wildcard",
+					Name:                 "Wildcard",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "!car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+					},
+				},
+			},
+			ExpectedConditionId: "Wildcard",
+			ExpectedScore:       3,
+		},
+		{
+			Alias: `Set of workflow factors`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "express",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "van", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Set",
+					Name:                 "Set",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard|premium",
+					VehicleType:          "van",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+					},
+				},
+			},
+			ExpectedConditionId: "Set",
+			ExpectedScore:       4,
+		},
+		{
+			Alias: `Option set beats option wildcard scoring the same`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "express",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "van", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "2",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "van", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Wildcard",
+					Name:                 "Wildcard",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "van",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "exp*",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+				domain.ContractCondition{
+					Id:                   "Set",
+					Name:                 "Set",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "van",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "express|premium",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			ExpectedConditionId: "Set",
+			ExpectedScore:       11,
+		},
+		{
+			Alias: `Option wildcard beats option fallback scoring the same`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "express",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "van", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "2",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "van", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Fallback",
+					Name:                 "Fallback",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "van",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+				domain.ContractCondition{
+					Id:                   "Wildcard",
+					Name:                 "Wildcard",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "van",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "exp*",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			ExpectedConditionId: "Wildcard",
+			ExpectedScore:       11,
+		},
+		{
+			Alias: `Option wildcard beats option fallback scoring the same by default`,
+			MovementsIn: []application.Movement{
+				newMovement("1", "checkin", func(mvmt *application.Movement) { mvmt.Option = "express"; mvmt.Vehicle.Type = "van" }),
+				newMovement("2", "parking", func(mvmt *application.Movement) { mvmt.Vehicle.Type = "van" }),
+			},
+			ConditionsIn: []domain.ContractCondition{
+				newCondition("A-Fallback", []string{"checkin", "parking"}, func(cond *domain.ContractCondition) { cond.VehicleType = "van" }),
+				newCondition("B-Wildcard", []string{"checkin", "parking"}, func(cond *domain.ContractCondition) {
+					cond.VehicleType = "van"
+					cond.MovementActivities[0].Option = "exp*"
+				}),
+			},
+			ExpectedConditionId: "B-Wildcard",
+			ExpectedScore:       11,
+		},
+		{
+			Alias: `Option wildcard beats option fallback scoring the same regardless of tie breakers`,
+			MovementsIn: []application.Movement{
+				newMovement("1", "checkin", func(mvmt *application.Movement) { mvmt.Option = "express"; mvmt.Vehicle.Type = "van" }),
+				newMovement("2", "parking", func(mvmt *application.Movement) { mvmt.Vehicle.Type = "van" }),
+			},
+			ConditionsIn: []domain.ContractCondition{
+				newCondition("A-Fallback", []string{"checkin", "parking"}, func(cond *domain.ContractCondition) { cond.VehicleType = "van" }),
+				newCondition("B-Wildcard", []string{"checkin", "parking"}, func(cond *domain.ContractCondition) {
+					cond.VehicleType = "van"
+					cond.MovementActivities[0].Option = "exp*"
+				}),
+			},
+			OptionsIn:           []application.Option{application.WithTieBreakers(application.TieBreakLowestConditionId())},
+			ExpectedConditionId: "B-Wildcard",
+			ExpectedScore:       11,
+		},
+		{
+			Alias: `Option wildcard beats option fallback scoring the same without tie breakers`,
+			MovementsIn: []application.Movement{
+				newMovement("1", "checkin", func(mvmt *application.Movement) { mvmt.Option = "express"; mvmt.Vehicle.Type = "van" }),
+				newMovement("2", "parking", func(mvmt *application.Movement) { mvmt.Vehicle.Type = "van" }),
+			},
+			ConditionsIn: []domain.ContractCondition{
+				newCondition("A-Fallback", []string{"checkin", "parking"}, func(cond *domain.ContractCondition) { cond.VehicleType = "van" }),
+				newCondition("B-Wildcard", []string{"checkin", "parking"}, func(cond *domain.ContractCondition) {
+					cond.VehicleType = "van"
+					cond.MovementActivities[0].Option = "exp*"
+				}),
+			},
+			OptionsIn:           []application.Option{application.WithTieBreakers()},
+			ExpectedConditionId: "B-Wildcard",
+			ExpectedScore:       11,
+		},
+		{
+			Alias: `Negated option rejects the movement`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "express",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "van", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "NotExpress",
+					Name:                 "NotExpress",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "van",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "!express",
+							Type:   "checkin",
+						},
+					},
+				},
+			},
+			ExpectedConditionId: "",
+			ExpectedScore:       0,
+		},
+		{
+			Alias: `Parent vehicle type matches child one`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "van", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "LightCommercial",
+					Name:                 "LightCommercial",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "light-commercial",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+					},
+				},
+			},
+			OptionsIn:           []application.Option{application.WithScorer(&application.WeightedScorer{Weights: application.DefaultScoreWeights(), Taxonomy: taxonomy})},
+			ExpectedConditionId: "LightCommercial",
+			ExpectedScore:       5,
+		},
+		{
+			Alias: `Closer vehicle type is preferred`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "van", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Any",
+					Name:                 "Any",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "any",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+					},
+				},
+				domain.ContractCondition{
+					Id:                   "LightCommercial",
+					Name:                 "LightCommercial",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "light-commercial",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+					},
+				},
+				domain.ContractCondition{
+					Id:                   "Van",
+					Name:                 "Van",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "van",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+					},
+				},
+			},
+			OptionsIn:           []application.Option{application.WithScorer(&application.WeightedScorer{Weights: application.DefaultScoreWeights(), Taxonomy: taxonomy})},
+			ExpectedConditionId: "Van",
+			ExpectedScore:       6,
+		},
+		{
+			Alias: `Closer parent vehicle type is preferred`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "van", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Any",
+					Name:                 "Any",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "any",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+					},
+				},
+				domain.ContractCondition{
+					Id:                   "LightCommercial",
+					Name:                 "LightCommercial",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "light-commercial",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+					},
+				},
+			},
+			OptionsIn:           []application.Option{application.WithScorer(&application.WeightedScorer{Weights: application.DefaultScoreWeights(), Taxonomy: taxonomy})},
+			ExpectedConditionId: "LightCommercial",
+			ExpectedScore:       5,
+		},
+		{
+			Alias: `Sibling vehicle type does not match`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "van", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Truck",
+					Name:                 "Truck",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "truck",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+					},
+				},
+			},
+			OptionsIn:           []application.Option{application.WithScorer(&application.WeightedScorer{Weights: application.DefaultScoreWeights(), Taxonomy: taxonomy})},
+			ExpectedConditionId: "",
+			ExpectedScore:       0,
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			ctx := context.Background()
+
+			actualResult, err := application.MatchMovements(ctx, tCase.MovementsIn, tCase.ConditionsIn, tCase.OptionsIn...)
+
+			assert.NoError(t, err)
+
+			actualConditionId := ""
+			for _, match := range actualResult.Bundles {
+				actualConditionId = match.ContractCondition.Id
+			}
+			assert.Equal(t, tCase.ExpectedConditionId, actualConditionId)
+			assert.Equal(t, tCase.ExpectedScore, actualResult.Score)
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func TestMatchMovements_Priority(t *testing.T) {
+
+	testCases := []struct {
+		Alias                string
+		MovementsIn          []application.Movement
+		ConditionsIn         []domain.ContractCondition
+		OptionsIn            []application.Option
+		ExpectedConditionIds []string
+		ExpectedScore        int
+		ExpectedUnmatched    []string
+		// ExpectedReasons holds rejection reasons by movement Id and contract condition Id joined with slash
+		ExpectedReasons map[string]application.RejectionReason
+	}{
+		{
+			Alias: `Higher priority wins regardless of score`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 9, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "2",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 10, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Generic",
+					Name:                 "Generic",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+				domain.ContractCondition{
+					Id:                   "Special",
+					Name:                 "Special",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					Priority:             1,
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			OptionsIn:            nil,
+			ExpectedConditionIds: []string{"Special"},
+			ExpectedScore:        6,
+			ExpectedUnmatched:    nil,
+			ExpectedReasons:      map[string]application.RejectionReason{},
+		},
+		{
+			Alias: `Priority weighted into score loses to higher score`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 9, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "2",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 10, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Generic",
+					Name:                 "Generic",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+				domain.ContractCondition{
+					Id:                   "Special",
+					Name:                 "Special",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					Priority:             1,
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			OptionsIn:            []application.Option{application.WithPriorityWeight(1)},
+			ExpectedConditionIds: []string{"Generic"},
+			ExpectedScore:        12,
+			ExpectedUnmatched:    nil,
+			ExpectedReasons:      map[string]application.RejectionReason{},
+		},
+		{
+			Alias: `Priority weighted into score wins over lower score`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 9, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "2",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 10, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Generic",
+					Name:                 "Generic",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+				domain.ContractCondition{
+					Id:                   "Special",
+					Name:                 "Special",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					Priority:             1,
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			OptionsIn:            []application.Option{application.WithPriorityWeight(10)},
+			ExpectedConditionIds: []string{"Special"},
+			ExpectedScore:        6,
+			ExpectedUnmatched:    nil,
+			ExpectedReasons:      map[string]application.RejectionReason{},
+		},
+		{
+			Alias: `Exclusive bundle suppresses listed bundle`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 9, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "2",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 10, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "3",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 11, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "4",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 12, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Special",
+					Name:                 "Special",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					Exclusive:            true,
+					Suppresses:           []string{"Generic"},
+					MaxInstances:         1,
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+				domain.ContractCondition{
+					Id:                   "Generic",
+					Name:                 "Generic",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MaxInstances:         1,
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+			},
+			OptionsIn:            nil,
+			ExpectedConditionIds: []string{"Special"},
+			ExpectedScore:        12,
+			ExpectedUnmatched:    []string{"3", "4"},
+			ExpectedReasons:      map[string]application.RejectionReason{"3/Generic": application.RejectionReasonSuppressed, "4/Generic": application.RejectionReasonSuppressed},
+		},
+		{
+			Alias: `Exclusive bundle suppresses all other conditions`,
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:       "1",
+					Type:     "checkin",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 9, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "2",
+					Type:     "parking",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 10, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+				application.Movement{
+					Id:       "3",
+					Type:     "wash",
+					Option:   "",
+					Date:     time.Date(2018, 01, 31, 11, 0, 0, 0, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				},
+			},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                   "Special",
+					Name:                 "Special",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					Exclusive:            true,
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "checkin",
+						},
+						{
+							Option: "",
+							Type:   "parking",
+						},
+					},
+				},
+				domain.ContractCondition{
+					Id:                   "Wash",
+					Name:                 "Wash",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: "987654",
+					MovementActivities: []domain.MovementActivity{
+						{
+							Option: "",
+							Type:   "wash",
+						},
+					},
+				},
+			},
+			OptionsIn:            nil,
+			ExpectedConditionIds: []string{"Special"},
+			ExpectedScore:        12,
+			ExpectedUnmatched:    []string{"3"},
+			ExpectedReasons:      map[string]application.RejectionReason{"3/Wash": application.RejectionReasonSuppressed},
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			ctx := context.Background()
+
+			actualResult, err := application.MatchMovements(ctx, tCase.MovementsIn, tCase.ConditionsIn, tCase.OptionsIn...)
+
+			assert.NoError(t, err)
+
+			actualConditionIds := []string(nil)
+			for _, match := range actualResult.Bundles {
+				actualConditionIds = append(actualConditionIds, match.ContractCondition.Id)
+			}
+			assert.Equal(t, tCase.ExpectedConditionIds, actualConditionIds)
+			assert.Equal(t, tCase.ExpectedScore, actualResult.Score)
+
+			actualUnmatched := []string(nil)
+			for _, mvmt := range actualResult.Unmatched {
+				actualUnmatched = append(actualUnmatched, mvmt.Id)
+			}
+			assert.Equal(t, tCase.ExpectedUnmatched, actualUnmatched)
+
+			actualReasons := map[string]application.RejectionReason{}
+			for _, rejection := range actualResult.Rejections {
+				actualReasons[rejection.Movement.Id+"/"+rejection.ContractCondition.Id] = rejection.Reason
+			}
+			for key, expectedReason := range tCase.ExpectedReasons {
+				assert.Equal(t, expectedReason, actualReasons[key], key)
+			}
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func TestMatchMovements_PriceObjective(t *testing.T) {
+
+	movements := []application.Movement{
+		application.Movement{
+			Id:       "1",
+			Type:     "checkin",
+			Option:   "",
+			Date:     time.Date(2018, 01, 31, 9, 0, 0, 0, time.UTC),
+			Branch:   application.Branch{Id: "6"},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+			User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		},
+		application.Movement{
+			Id:       "2",
+			Type:     "parking",
+			Option:   "",
+			Date:     time.Date(2018, 01, 31, 10, 0, 0, 0, time.UTC),
+			Branch:   application.Branch{Id: "6"},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+			User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		},
+	}
+
+	conds := []domain.ContractCondition{
+		domain.ContractCondition{
+			Id:                   "Cheap",
+			Name:                 "Cheap",
+			WorkflowType:         "turnaround",
+			WorkflowFactor:       "standard",
+			VehicleType:          "car",
+			BranchIdentifier:     "6",
+			ContractorIdentifier: "987654",
+			Price:                domain.PriceModel{Currency: "EUR", Flat: "10.00"},
+			MovementActivities: []domain.MovementActivity{
+				{
+					Option: "",
+					Type:   "checkin",
+				},
+				{
+					Option: "",
+					Type:   "parking",
+				},
+			},
+		},
+		domain.ContractCondition{
+			Id:                   "Expensive",
+			Name:                 "Expensive",
+			WorkflowType:         "turnaround",
+			WorkflowFactor:       "standard",
+			VehicleType:          "",
+			BranchIdentifier:     "6",
+			ContractorIdentifier: "987654",
+			Price:                domain.PriceModel{Currency: "EUR", Flat: "9.99", PerActivity: map[string]string{"parking": "0.02"}},
+			MovementActivities: []domain.MovementActivity{
+				{
+					Option: "",
+					Type:   "checkin",
+				},
+				{
+					Option: "",
+					Type:   "parking",
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		Alias                string
+		OptionsIn            []application.Option
+		ExpectedConditionIds []string
+		ExpectedScore        int
+	}{
+		{
+			Alias:                `Best score wins by default`,
+			OptionsIn:            nil,
+			ExpectedConditionIds: []string{"Cheap"},
+			ExpectedScore:        12,
+		},
+		{
+			Alias:                `Highest price wins`,
+			OptionsIn:            []application.Option{application.WithPriceObjective(application.MaximizePrice)},
+			ExpectedConditionIds: []string{"Expensive"},
+			ExpectedScore:        6,
+		},
+		{
+			Alias:                `Lowest price wins`,
+			OptionsIn:            []application.Option{application.WithPriceObjective(application.MinimizePrice)},
+			ExpectedConditionIds: []string{"Cheap"},
+			ExpectedScore:        12,
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			ctx := context.Background()
+
+			actualResult, err := application.MatchMovements(ctx, movements, conds, tCase.OptionsIn...)
+
+			assert.NoError(t, err)
+
+			actualConditionIds := []string(nil)
+			for _, match := range actualResult.Bundles {
+				actualConditionIds = append(actualConditionIds, match.ContractCondition.Id)
+			}
+			assert.Equal(t, tCase.ExpectedConditionIds, actualConditionIds)
+			assert.Equal(t, tCase.ExpectedScore, actualResult.Score)
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func TestMatchMovements_Objectives(t *testing.T) {
+
+	movements := []application.Movement{
+		application.Movement{
+			Id:       "1",
+			Type:     "checkin",
+			Option:   "",
+			Date:     time.Date(2018, 01, 31, 9, 0, 0, 0, time.UTC),
+			Branch:   application.Branch{Id: "6"},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+			User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		},
+		application.Movement{
+			Id:       "2",
+			Type:     "parking",
+			Option:   "",
+			Date:     time.Date(2018, 01, 31, 10, 0, 0, 0, time.UTC),
+			Branch:   application.Branch{Id: "6"},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+			User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		},
+		application.Movement{
+			Id:       "3",
+			Type:     "wash",
+			Option:   "",
+			Date:     time.Date(2018, 01, 31, 11, 0, 0, 0, time.UTC),
+			Branch:   application.Branch{Id: "6"},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+			User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		},
+	}
+
+	conds := []domain.ContractCondition{
+		domain.ContractCondition{
+			Id:                   "Pair",
+			Name:                 "Pair",
+			WorkflowType:         "turnaround",
+			WorkflowFactor:       "standard",
+			VehicleType:          "car",
+			BranchIdentifier:     "6",
+			ContractorIdentifier: "987654",
+			Price:                domain.PriceModel{Currency: "EUR", Flat: "20.00"},
+			MovementActivities: []domain.MovementActivity{
+				{
+					Option: "",
+					Type:   "checkin",
+				},
+				{
+					Option: "",
+					Type:   "parking",
+				},
+			},
+		},
+		domain.ContractCondition{
+			Id:                   "CheapTriple",
+			Name:                 "CheapTriple",
+			WorkflowType:         "turnaround",
+			WorkflowFactor:       "standard",
+			VehicleType:          "",
+			BranchIdentifier:     "6",
+			ContractorIdentifier: "987654",
+			Price:                domain.PriceModel{Currency: "EUR", Flat: "15.00"},
+			MovementActivities: []domain.MovementActivity{
+				{
+					Option: "",
+					Type:   "checkin",
+				},
+				{
+					Option: "",
+					Type:   "parking",
+				},
+				{
+					Option: "",
+					Type:   "wash",
+				},
+			},
+		},
+		domain.ContractCondition{
+			Id:                   "ExpensiveTriple",
+			Name:                 "ExpensiveTriple",
+			WorkflowType:         "turnaround",
+			WorkflowFactor:       "standard",
+			VehicleType:          "",
+			BranchIdentifier:     "6",
+			ContractorIdentifier: "987654",
+			Price:                domain.PriceModel{Currency: "EUR", Flat: "18.00"},
+			MovementActivities: []domain.MovementActivity{
+				{
+					Option: "",
+					Type:   "checkin",
+				},
+				{
+					Option: "",
+					Type:   "parking",
+				},
+				{
+					Option: "",
+					Type:   "wash",
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		Alias                string
+		OptionsIn            []application.Option
+		ExpectedConditionIds []string
+	}{
+		{
+			Alias:                `Best score wins by default`,
+			OptionsIn:            nil,
+			ExpectedConditionIds: []string{"Pair"},
+		},
+		{
+			Alias:                `Score objective`,
+			OptionsIn:            []application.Option{application.WithObjectives(application.ObjectiveScore())},
+			ExpectedConditionIds: []string{"Pair"},
+		},
+		{
+			Alias:                `More matched movements then higher revenue`,
+			OptionsIn:            []application.Option{application.WithObjectives(application.ObjectiveMatchedMovements(), application.ObjectiveRevenue())},
+			ExpectedConditionIds: []string{"ExpensiveTriple"},
+		},
+		{
+			Alias:                `Fewer unmatched movements then lower customer cost`,
+			OptionsIn:            []application.Option{application.WithObjectives(application.ObjectiveFewerUnmatched(), application.ObjectiveCustomerCost())},
+			ExpectedConditionIds: []string{"CheapTriple"},
+		},
+		{
+			Alias:                `Higher revenue`,
+			OptionsIn:            []application.Option{application.WithObjectives(application.ObjectiveRevenue())},
+			ExpectedConditionIds: []string{"Pair"},
+		},
+		{
+			Alias:                `Lower customer cost`,
+			OptionsIn:            []application.Option{application.WithObjectives(application.ObjectiveCustomerCost())},
+			ExpectedConditionIds: []string{"CheapTriple"},
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			ctx := context.Background()
+
+			actualResult, err := application.MatchMovements(ctx, movements, conds, tCase.OptionsIn...)
+
+			assert.NoError(t, err)
+
+			actualConditionIds := []string(nil)
+			for _, match := range actualResult.Bundles {
+				actualConditionIds = append(actualConditionIds, match.ContractCondition.Id)
+			}
+			assert.Equal(t, tCase.ExpectedConditionIds, actualConditionIds)
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func TestMatchMovements_ObjectiveValueIsKept(t *testing.T) {
+
+	movements := []application.Movement{
+		{
+			Id:       "1",
+			Type:     "checkin",
+			Date:     time.Date(2018, 01, 31, 16, 59, 59, 0, time.UTC),
+			Branch:   application.Branch{Id: "6"},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+			User:     application.User{Contractor: func() *string { s := "987654"; return &s }(), Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		},
+	}
+
+	conditions := []domain.ContractCondition{
+		{
+			Id:                   "Checkin",
+			Name:                 "Checkin",
+			WorkflowType:         "turnaround",
+			WorkflowFactor:       "standard",
+			VehicleType:          "car",
+			BranchIdentifier:     "6",
+			ContractorIdentifier: "987654",
+			Priority:             3,
+			MovementActivities:   []domain.MovementActivity{{Type: "checkin"}},
+		},
+	}
+
+	// The objective hands out the same value every time, so weighting priority into it must not modify it.
+	value := big.NewRat(7, 1)
+	objective := application.ObjectiveFunc(func(combination []application.Match) *big.Rat { return value })
+
+	ctx := context.Background()
+
+	_, err := application.MatchMovements(ctx, movements, conditions, application.WithObjectives(objective), application.WithPriorityWeight(10))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "7", value.RatString())
+}
+
+func TestMatchMovements_ManyConditions(t *testing.T) {
+
+	// Every contract condition fits only the pair of movements of its own vehicle type,
+	// so plain enumeration would go through every subset of conditions.
+	const conditionsCount = 60
+
+	contractor := "987654"
+	movements := []application.Movement{}
+	conditions := []domain.ContractCondition{}
+
+	for condNo := 0; condNo < conditionsCount; condNo++ {
+
+		vehicleType := "type-" + strconv.Itoa(condNo)
+
+		for _, movementType := range []string{"checkin", "parking"} {
+			movements = append(movements, application.Movement{
+				Id:       vehicleType + "-" + movementType,
+				Type:     movementType,
+				Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+				Branch:   application.Branch{Id: "6"},
+				Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+				User:     application.User{Contractor: &contractor, Id: "TheUserId"},
+				Vehicle:  application.Vehicle{Type: vehicleType, Id: vehicleType},
+			})
+		}
+
+		conditions = append(conditions, domain.ContractCondition{
+			Id:                   "Turnaround-" + vehicleType,
+			Name:                 "Turnaround",
+			WorkflowType:         "turnaround",
+			WorkflowFactor:       "standard",
+			VehicleType:          vehicleType,
+			BranchIdentifier:     "6",
+			ContractorIdentifier: contractor,
+			MovementActivities:   []domain.MovementActivity{{Type: "checkin"}, {Type: "parking"}},
+		})
+	}
+
+	ctx := context.Background()
+
+	actualResult, err := application.MatchMovements(ctx, movements, conditions, application.WithSearchBudget(100000))
+
+	assert.NoError(t, err)
+	assert.Len(t, actualResult.Bundles, conditionsCount)
+	assert.Empty(t, actualResult.Unmatched)
+	assert.Equal(t, conditionsCount*2*6, actualResult.Score)
+}
+
+func TestMatchMovements_RepeatedConditions(t *testing.T) {
+
+	contractor := "987654"
+
+	// pairs returns checkin/parking pairs, where any checkin could be paired with any parking.
+	pairs := func(count int) []application.Movement {
+		movements := []application.Movement{}
+		for pairNo := 0; pairNo < count; pairNo++ {
+			for _, movementType := range []string{"checkin", "parking"} {
+				movements = append(movements, application.Movement{
+					Id:       strconv.Itoa(pairNo) + "-" + movementType,
+					Type:     movementType,
+					Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch:   application.Branch{Id: "6"},
+					Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+					User:     application.User{Contractor: &contractor, Id: "TheUserId"},
+					Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+				})
+			}
+		}
+		return movements
+	}
+
+	testCases := []struct {
+		Alias                  string
+		MovementsIn            []application.Movement
+		MaxInstancesIn         int
+		ExpectedBundlesCount   int
+		ExpectedUnmatchedCount int
+		ExpectedScore          int
+	}{
+		{
+			Alias:                  `5 pairs match to 1 CC with 2 MA 5 times`,
+			MovementsIn:            pairs(5),
+			MaxInstancesIn:         0,
+			ExpectedBundlesCount:   5,
+			ExpectedUnmatchedCount: 0,
+			ExpectedScore:          5 * 12,
+		},
+		{
+			Alias:                  `5 pairs match to 1 CC with 2 MA up to MaxInstances`,
+			MovementsIn:            pairs(5),
+			MaxInstancesIn:         2,
+			ExpectedBundlesCount:   2,
+			ExpectedUnmatchedCount: 6,
+			ExpectedScore:          2 * 12,
+		},
+		{
+			Alias:                  `MaxInstances above what movements allow`,
+			MovementsIn:            pairs(3)[:5],
+			MaxInstancesIn:         10,
+			ExpectedBundlesCount:   2,
+			ExpectedUnmatchedCount: 1,
+			ExpectedScore:          2 * 12,
+		},
+		{
+			Alias:                  `Dozens of interchangeable pairs`,
+			MovementsIn:            pairs(30),
+			MaxInstancesIn:         0,
+			ExpectedBundlesCount:   30,
+			ExpectedUnmatchedCount: 0,
+			ExpectedScore:          30 * 12,
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			conditions := []domain.ContractCondition{
+				{
+					Id:                   "Turnaround",
+					Name:                 "Turnaround",
+					WorkflowType:         "turnaround",
+					WorkflowFactor:       "standard",
+					VehicleType:          "car",
+					BranchIdentifier:     "6",
+					ContractorIdentifier: contractor,
+					MaxInstances:         tCase.MaxInstancesIn,
+					MovementActivities:   []domain.MovementActivity{{Type: "checkin"}, {Type: "parking"}},
+				},
+			}
+
+			ctx := context.Background()
+
+			actualResult, err := application.MatchMovements(ctx, tCase.MovementsIn, conditions, application.WithSearchBudget(100000))
+
+			assert.NoError(t, err)
+			assert.Len(t, actualResult.Bundles, tCase.ExpectedBundlesCount)
+			assert.Len(t, actualResult.Unmatched, tCase.ExpectedUnmatchedCount)
+			assert.Equal(t, tCase.ExpectedScore, actualResult.Score)
+
+			for _, match := range actualResult.Bundles {
+				assert.Len(t, match.Movements, 2)
+			}
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func TestMatchMovements_RejectionsOfVersions(t *testing.T) {
+
+	december := time.Date(2018, 12, 31, 12, 0, 0, 0, time.UTC)
+	january := time.Date(2019, 01, 02, 12, 0, 0, 0, time.UTC)
+	inMonth := func(date time.Time) func(mvmt *application.Movement) {
+		return func(mvmt *application.Movement) { mvmt.Date = date }
+	}
+
+	movements := []application.Movement{
+		newMovement("1", "checkin", inMonth(december)),
+		newMovement("2", "parking", inMonth(december)),
+		newMovement("3", "checkin", inMonth(january)),
+		newMovement("4", "parking", inMonth(january)),
+		newMovement("5", "checkin", inMonth(january)),
+	}
+
+	// Versions share the Id, but each of them is capped by its own MaxInstances.
+	conditions := []domain.ContractCondition{
+		newCondition("Turnaround", []string{"checkin", "parking"}, func(cond *domain.ContractCondition) {
+			cond.Version = 2018
+			cond.MaxInstances = 2
+			cond.ValidTo = time.Date(2019, 01, 01, 0, 0, 0, 0, time.UTC)
+		}),
+		newCondition("Turnaround", []string{"checkin", "parking"}, func(cond *domain.ContractCondition) {
+			cond.Version = 2019
+			cond.MaxInstances = 2
+			cond.ValidFrom = time.Date(2019, 01, 01, 0, 0, 0, 0, time.UTC)
+		}),
+	}
+
+	ctx := context.Background()
+
+	actualResult, err := application.MatchMovements(ctx, movements, conditions)
+
+	assert.NoError(t, err)
+	assert.Len(t, actualResult.Bundles, 2)
+	assert.Equal(t, movements[4:], actualResult.Unmatched)
+
+	actualReasons := map[int]application.RejectionReason{}
+	for _, rejection := range actualResult.Rejections {
+		actualReasons[rejection.ContractCondition.Version] = rejection.Reason
+	}
+	assert.Equal(t, map[int]application.RejectionReason{
+		2018: application.RejectionReasonOutOfValidity,
+		2019: application.RejectionReasonInsufficientMovements,
+	}, actualReasons)
+}
+
+func TestMatchMovements_Partitions(t *testing.T) {
+
+	movement := func(id, movementType, branch, contractor string) application.Movement {
+		return application.Movement{
+			Id:       id,
+			Type:     movementType,
+			Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+			Branch:   application.Branch{Id: branch},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+			User:     application.User{Contractor: &contractor, Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		}
+	}
+
+	condition := func(id, branch, vehicleType string) domain.ContractCondition {
+		return domain.ContractCondition{
+			Id:                   id,
+			Name:                 "Turnaround",
+			WorkflowType:         "turnaround",
+			WorkflowFactor:       "standard",
+			VehicleType:          vehicleType,
+			BranchIdentifier:     branch,
+			ContractorIdentifier: "987654",
+			MovementActivities:   []domain.MovementActivity{{Type: "checkin"}, {Type: "parking"}},
+		}
+	}
+
+	testCases := []struct {
+		Alias                  string
+		MovementsIn            []application.Movement
+		ConditionsIn           []domain.ContractCondition
+		ExpectedErr            error
+		ExpectedConditionIds   []string
+		ExpectedUnmatched      []string
+		ExpectedTiedCount      int
+		ExpectedSuppressedMvmt []string
+		ExpectedTiedMvmt       []string
+	}{
+		{
+			Alias: `Branches are matched separately`,
+			MovementsIn: []application.Movement{
+				movement("1", "checkin", "6", "987654"),
+				movement("2", "checkin", "7", "987654"),
+				movement("3", "parking", "6", "987654"),
+				movement("4", "parking", "7", "987654"),
+			},
+			ConditionsIn:         []domain.ContractCondition{condition("Branch6", "6", "car"), condition("Branch7", "7", "car")},
+			ExpectedConditionIds: []string{"Branch6", "Branch7"},
+			ExpectedUnmatched:    nil,
+		},
+		{
+			Alias: `Movements fitting no contract condition keep their order`,
+			MovementsIn: []application.Movement{
+				movement("1", "checkin", "8", "987654"),
+				movement("2", "checkin", "6", "987654"),
+				movement("3", "parking", "6", "123456"),
+				movement("4", "wash", "6", "987654"),
+				movement("5", "parking", "6", "987654"),
+			},
+			ConditionsIn:         []domain.ContractCondition{condition("Branch6", "6", "car")},
+			ExpectedConditionIds: []string{"Branch6"},
+			ExpectedUnmatched:    []string{"1", "3", "4"},
+		},
+		{
+			Alias: `Exclusive contract condition suppresses one of another branch`,
+			MovementsIn: []application.Movement{
+				movement("1", "checkin", "6", "987654"),
+				movement("2", "parking", "6", "987654"),
+				movement("3", "checkin", "7", "987654"),
+				movement("4", "parking", "7", "987654"),
+			},
+			ConditionsIn: []domain.ContractCondition{
+				func() domain.ContractCondition {
+					cond := condition("Special", "6", "car")
+					cond.Exclusive = true
+					cond.Suppresses = []string{"Generic"}
+					return cond
+				}(),
+				condition("Generic", "7", ""),
+			},
+			ExpectedConditionIds:   []string{"Special"},
+			ExpectedUnmatched:      []string{"3", "4"},
+			ExpectedSuppressedMvmt: []string{"3", "4"},
+		},
+		{
+			Alias: `Unresolved tie in one branch leaves movements of that branch only unmatched`,
+			MovementsIn: []application.Movement{
+				movement("1", "checkin", "6", "987654"),
+				movement("2", "parking", "6", "987654"),
+				movement("3", "checkin", "7", "987654"),
+				movement("4", "parking", "7", "987654"),
+			},
+			ConditionsIn:         []domain.ContractCondition{condition("Branch6", "6", "car"), condition("First", "7", "car"), condition("Second", "7", "car")},
+			ExpectedErr:          application.ErrAmbiguousResult,
+			ExpectedConditionIds: []string{"Branch6"},
+			ExpectedUnmatched:    []string{"3", "4"},
+			ExpectedTiedCount:    2,
+			ExpectedTiedMvmt:     []string{"3", "3", "4", "4"},
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			ctx := context.Background()
+
+			actualResult, err := application.MatchMovements(ctx, tCase.MovementsIn, tCase.ConditionsIn)
+
+			if tCase.ExpectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tCase.ExpectedErr)
+			}
+
+			actualConditionIds := []string(nil)
+			for _, match := range actualResult.Bundles {
+				actualConditionIds = append(actualConditionIds, match.ContractCondition.Id)
+			}
+			assert.Equal(t, tCase.ExpectedConditionIds, actualConditionIds)
+
+			actualUnmatched := []string(nil)
+			for _, mvmt := range actualResult.Unmatched {
+				actualUnmatched = append(actualUnmatched, mvmt.Id)
+			}
+			assert.Equal(t, tCase.ExpectedUnmatched, actualUnmatched)
+
+			assert.Len(t, actualResult.TiedCombinations, tCase.ExpectedTiedCount)
+
+			actualSuppressedMvmt := []string(nil)
+			for _, rejection := range actualResult.Rejections {
+				if rejection.Reason == application.RejectionReasonSuppressed {
+					actualSuppressedMvmt = append(actualSuppressedMvmt, rejection.Movement.Id)
+				}
+			}
+			assert.Equal(t, tCase.ExpectedSuppressedMvmt, actualSuppressedMvmt)
+
+			actualTiedMvmt := []string(nil)
+			for _, rejection := range actualResult.Rejections {
+				if rejection.Reason == application.RejectionReasonTieUnresolved {
+					actualTiedMvmt = append(actualTiedMvmt, rejection.Movement.Id)
+				}
+			}
+			assert.ElementsMatch(t, tCase.ExpectedTiedMvmt, actualTiedMvmt)
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func TestMatchMovements_ManyPartitions(t *testing.T) {
+
+	// Every branch has a few interchangeable contract conditions, so solving all branches at once
+	// would go through the product of their alternatives.
+	const branchesCount = 200
+
+	contractor := "987654"
+	movements := []application.Movement{}
+	conditions := []domain.ContractCondition{}
+
+	for branchNo := 0; branchNo < branchesCount; branchNo++ {
+
+		branch := strconv.Itoa(branchNo)
+
+		for _, movementType := range []string{"checkin", "wash", "parking"} {
+			movements = append(movements, application.Movement{
+				Id:       branch + "-" + movementType,
+				Type:     movementType,
+				Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+				Branch:   application.Branch{Id: branch},
+				Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+				User:     application.User{Contractor: &contractor, Id: "TheUserId"},
+				Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+			})
+		}
+
+		for _, activities := range [][]domain.MovementActivity{
+			{{Type: "checkin"}, {Type: "wash"}, {Type: "parking"}},
+			{{Type: "checkin"}, {Type: "parking"}},
+			{{Type: "wash"}, {Type: "parking"}},
+		} {
+			conditions = append(conditions, domain.ContractCondition{
+				Id:                   branch + "-" + strconv.Itoa(len(activities)) + "-" + activities[0].Type,
+				Name:                 "Turnaround",
+				WorkflowType:         "turnaround",
+				WorkflowFactor:       "standard",
+				VehicleType:          "car",
+				BranchIdentifier:     branch,
+				ContractorIdentifier: contractor,
+				MovementActivities:   activities,
+			})
+		}
+	}
+
+	ctx := context.Background()
+
+	actualResult, err := application.MatchMovements(ctx, movements, conditions, application.WithSearchBudget(100000))
+
+	assert.NoError(t, err)
+	assert.Len(t, actualResult.Bundles, branchesCount)
+	assert.Empty(t, actualResult.Unmatched)
+	assert.Equal(t, branchesCount*3*6, actualResult.Score)
+}
+
+func TestMatchMovements_Workers(t *testing.T) {
+
+	const branchesCount = 12
+
+	contractor := "987654"
+	movements := []application.Movement{}
+	conditions := []domain.ContractCondition{}
+
+	for branchNo := 0; branchNo < branchesCount; branchNo++ {
+
+		branch := strconv.Itoa(branchNo)
+
+		// Every third branch misses the parking, so its checkin and wash are matched to single activity conditions or left unmatched.
+		movementTypes := []string{"checkin", "wash", "parking"}
+		if branchNo%3 == 0 {
+			movementTypes = movementTypes[:2]
+		}
+
+		for _, movementType := range movementTypes {
+			movements = append(movements, application.Movement{
+				Id:       branch + "-" + movementType,
+				Type:     movementType,
+				Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+				Branch:   application.Branch{Id: branch},
+				Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+				User:     application.User{Contractor: &contractor, Id: "TheUserId"},
+				Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+			})
+		}
+
+		// The wash fits any vehicle type, so the turnaround with the wash outscores the one without it along with the wash.
+		// The latter goes first, so the search interrupted by its budget ends up with it.
+		for _, cond := range []struct {
+			vehicleType string
+			activities  []domain.MovementActivity
+		}{
+			{vehicleType: "car", activities: []domain.MovementActivity{{Type: "checkin"}, {Type: "parking"}}},
+			{vehicleType: "car", activities: []domain.MovementActivity{{Type: "checkin"}, {Type: "wash"}, {Type: "parking"}}},
+			{vehicleType: "", activities: []domain.MovementActivity{{Type: "wash"}}},
+		} {
+			conditions = append(conditions, domain.ContractCondition{
+				Id:                   branch + "-" + strconv.Itoa(len(cond.activities)) + "-" + cond.activities[0].Type,
+				Name:                 "Turnaround",
+				WorkflowType:         "turnaround",
+				WorkflowFactor:       "standard",
+				VehicleType:          cond.vehicleType,
+				BranchIdentifier:     branch,
+				ContractorIdentifier: contractor,
+				MovementActivities:   cond.activities,
+			})
+		}
+	}
+
+	ctx := context.Background()
+
+	testCases := []struct {
+		Alias       string
+		WorkersIn   int
+		OptionsIn   []application.Option
+		ExpectedErr error
+	}{
+		{
+			Alias:     `Fewer workers than partitions`,
+			WorkersIn: 4,
+		},
+		{
+			Alias:     `More workers than partitions`,
+			WorkersIn: branchesCount * 2,
+		},
+		{
+			Alias:     `Non positive number of workers keeps the default`,
+			WorkersIn: 0,
+		},
+		{
+			Alias:       `Search budget is shared by partitions regardless of workers`,
+			WorkersIn:   4,
+			OptionsIn:   []application.Option{application.WithSearchBudget(branchesCount * 5)},
+			ExpectedErr: application.ErrSearchBudgetExceeded,
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			expectedResult, expectedErr := application.MatchMovements(ctx, movements, conditions, append(tCase.OptionsIn, application.WithWorkers(1))...)
+			assert.ErrorIs(t, expectedErr, tCase.ExpectedErr)
+
+			for run := 0; run < 3; run++ {
+
+				actualResult, err := application.MatchMovements(ctx, movements, conditions, append(tCase.OptionsIn, application.WithWorkers(tCase.WorkersIn))...)
+
+				assert.Equal(t, expectedErr, err)
+				assert.Equal(t, expectedResult, actualResult)
+			}
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func TestMatchMovements_WorkersOnContextDone(t *testing.T) {
+
+	contractor := "987654"
+	movements := []application.Movement{}
+	conditions := []domain.ContractCondition{}
+
+	for branchNo := 0; branchNo < 20; branchNo++ {
+
+		branch := strconv.Itoa(branchNo)
+
+		for _, movementType := range []string{"checkin", "parking"} {
+			movements = append(movements, application.Movement{
+				Id:       branch + "-" + movementType,
+				Type:     movementType,
+				Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+				Branch:   application.Branch{Id: branch},
+				Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+				User:     application.User{Contractor: &contractor, Id: "TheUserId"},
+				Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+			})
+		}
+
+		conditions = append(conditions, domain.ContractCondition{
+			Id:                   branch,
+			Name:                 "Turnaround",
+			WorkflowType:         "turnaround",
+			WorkflowFactor:       "standard",
+			VehicleType:          "car",
+			BranchIdentifier:     branch,
+			ContractorIdentifier: contractor,
+			MovementActivities:   []domain.MovementActivity{{Type: "checkin"}, {Type: "parking"}},
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	scorer := &cancellingScorer{
+		Scorer:              application.NewWeightedScorer(application.DefaultScoreWeights()),
+		cancel:              cancel,
+		cancelOnConditionId: "10",
+	}
+
+	actualResult, err := application.MatchMovements(ctx, movements, conditions, application.WithScorer(scorer), application.WithWorkers(4))
+
+	assert.ErrorIs(t, err, application.ErrPartialResult)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	actualMatchedCount := 0
+	for _, match := range actualResult.Bundles {
+		actualMatchedCount += len(match.Movements)
+	}
+	assert.Less(t, len(actualResult.Bundles), len(conditions))
+	assert.Len(t, actualResult.Unmatched, len(movements)-actualMatchedCount)
+}
+
+func TestMatchMovements_SearchBudgetOfPartitions(t *testing.T) {
+
+	// Every branch has the same movements and contract conditions, so either all of them exceed their share of the budget or none does,
+	// whichever is matched first. The turnaround without the wash goes first, so the search interrupted by its budget ends up with it.
+	const branchesCount = 12
+
+	movements := []application.Movement{}
+	conditions := []domain.ContractCondition{}
+
+	for branchNo := 0; branchNo < branchesCount; branchNo++ {
+
+		branch := strconv.Itoa(branchNo)
+
+		for _, movementType := range []string{"checkin", "wash", "parking"} {
+			movements = append(movements, newMovement(branch+"-"+movementType, movementType, func(mvmt *application.Movement) {
+				mvmt.Branch.Id = branch
+			}))
+		}
+
+		for _, name := range []string{"Checkin-Parking", "Checkin-Wash-Parking"} {
+			conditions = append(conditions, newCondition(branch+"-"+name, strings.Split(strings.ToLower(name), "-"), func(cond *domain.ContractCondition) {
+				cond.Name = name
+				cond.BranchIdentifier = branch
+			}))
+		}
+	}
+
+	testCases := []struct {
+		Alias                  string
+		BudgetIn               int
+		ExpectedErr            error
+		ExpectedConditionNames map[string]int
+	}{
+		{
+			Alias:                  `Every branch is within its share`,
+			BudgetIn:               branchesCount * 100,
+			ExpectedConditionNames: map[string]int{"Checkin-Wash-Parking": branchesCount},
+		},
+		{
+			Alias:                  `Every branch exceeds its share`,
+			BudgetIn:               branchesCount * 5,
+			ExpectedErr:            application.ErrSearchBudgetExceeded,
+			ExpectedConditionNames: map[string]int{"Checkin-Parking": branchesCount},
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			ctx := context.Background()
+
+			actualResult, err := application.MatchMovements(ctx, movements, conditions, application.WithSearchBudget(tCase.BudgetIn), application.WithWorkers(1))
+
+			if tCase.ExpectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tCase.ExpectedErr)
+			}
+
+			actualConditionNames := map[string]int{}
+			for _, match := range actualResult.Bundles {
+				actualConditionNames[match.ContractCondition.Name]++
+			}
+			assert.Equal(t, tCase.ExpectedConditionNames, actualConditionNames)
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func TestMatchMovements_TieDoesNotDependOnOrder(t *testing.T) {
+
+	withOption := func(option string) func(mvmt *application.Movement) {
+		return func(mvmt *application.Movement) { mvmt.Option = option }
+	}
+	withActivityOption := func(option string) func(cond *domain.ContractCondition) {
+		return func(cond *domain.ContractCondition) { cond.MovementActivities[0].Option = option }
+	}
+
+	// Either parking could go to the bundle, while the other one goes to its own single activity condition scoring the same.
+	conditions := []domain.ContractCondition{
+		newCondition("Turnaround", []string{"checkin", "parking"}),
+		newCondition("ParkingX", []string{"parking"}, withActivityOption("x")),
+		newCondition("ParkingY", []string{"parking"}, withActivityOption("y")),
+	}
+
+	checkin, parkingX, parkingY := newMovement("1", "checkin"), newMovement("2", "parking", withOption("x")), newMovement("3", "parking", withOption("y"))
+
+	testCases := []struct {
+		Alias       string
+		MovementsIn []application.Movement
+		OptionsIn   []application.Option
+	}{
+		{
+			Alias:       `Bundle goes first`,
+			MovementsIn: []application.Movement{checkin, parkingX, parkingY},
+		},
+		{
+			Alias:       `Bundle goes last`,
+			MovementsIn: []application.Movement{parkingY, parkingX, checkin},
+		},
+		{
+			Alias:       `Score objective ranks the same`,
+			MovementsIn: []application.Movement{checkin, parkingX, parkingY},
+			OptionsIn:   []application.Option{application.WithObjectives(application.ObjectiveScore())},
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			ctx := context.Background()
+
+			actualResult, err := application.MatchMovements(ctx, tCase.MovementsIn, conditions, tCase.OptionsIn...)
+
+			assert.ErrorIs(t, err, application.ErrAmbiguousResult)
+			assert.Empty(t, actualResult.Bundles)
+			assert.Len(t, actualResult.TiedCombinations, 2)
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func TestMatchMovements_DeadlineOnManyTies(t *testing.T) {
+
+	// Any checkin could be paired with parkings within MaxSpan by either of conditions scoring the same,
+	// so there are too many ways to match movements to go through all of them.
+	const pairsCount = 100
+
+	movements := []application.Movement{}
+	for pairNo := 0; pairNo < pairsCount; pairNo++ {
+		for movementNo, movementType := range []string{"checkin", "parking"} {
+			movements = append(movements, newMovement(strconv.Itoa(pairNo)+"-"+movementType, movementType, func(mvmt *application.Movement) {
+				mvmt.Date = time.Date(2018, 01, 31, 0, 2*pairNo+movementNo, 0, 0, time.UTC)
+				mvmt.Vehicle.Id = strconv.Itoa(pairNo)
+			}))
+		}
+	}
+
+	withMaxSpan := func(cond *domain.ContractCondition) { cond.MaxSpan = 30 * time.Minute }
+	conditions := []domain.ContractCondition{
+		newCondition("Turnaround", []string{"checkin", "parking"}, withMaxSpan),
+		newCondition("TurnaroundAgain", []string{"checkin", "parking"}, withMaxSpan),
+	}
+
+	const timeout = 200 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	started := time.Now()
+	actualResult, err := application.MatchMovements(ctx, movements, conditions)
+
+	assert.ErrorIs(t, err, application.ErrPartialResult)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(started), 10*timeout)
+	assert.Len(t, actualResult.Bundles, pairsCount)
+}
+
+func TestMatchMovements_SingleActivityAlongsideBundles(t *testing.T) {
+
+	condition := func(id, flat string, activityTypes ...string) domain.ContractCondition {
+		return newCondition(id, activityTypes, func(cond *domain.ContractCondition) {
+			cond.Price = domain.PriceModel{Currency: "EUR", Flat: flat}
+		})
+	}
+
+	bundleAndSingles := []domain.ContractCondition{
+		condition("Turnaround", "100.00", "checkin", "parking"),
+		condition("Checkin", "1.00", "checkin"),
+		condition("Parking", "1.00", "parking"),
+	}
+
+	prioritized := condition("Checkin", "1.00", "checkin")
+	prioritized.Priority = 1
+
+	testCases := []struct {
+		Alias                 string
+		MovementsIn           []application.Movement
+		ConditionsIn          []domain.ContractCondition
+		OptionsIn             []application.Option
+		ExpectedConditionIds  []string
+		ExpectedIsTie         bool
+		ExpectedIsTieResolved bool
+	}{
+		{
+			Alias:                `Bundle wins over single activity conditions scoring the same`,
+			MovementsIn:          []application.Movement{newMovement("1", "checkin"), newMovement("2", "parking")},
+			ConditionsIn:         bundleAndSingles,
+			ExpectedConditionIds: []string{"Turnaround"},
+		},
+		{
+			Alias:                `Single activity conditions win over the bundle by lower customer cost`,
+			MovementsIn:          []application.Movement{newMovement("1", "checkin"), newMovement("2", "parking")},
+			ConditionsIn:         bundleAndSingles,
+			OptionsIn:            []application.Option{application.WithObjectives(application.ObjectiveCustomerCost())},
+			ExpectedConditionIds: []string{"Checkin", "Parking"},
+		},
+		{
+			Alias:                `Single activity conditions win over the bundle by higher priority`,
+			MovementsIn:          []application.Movement{newMovement("1", "checkin"), newMovement("2", "parking")},
+			ConditionsIn:         []domain.ContractCondition{bundleAndSingles[0], prioritized, bundleAndSingles[2]},
+			ExpectedConditionIds: []string{"Checkin", "Parking"},
+		},
+		{
+			Alias:         `Single activity conditions scoring the same tie`,
+			MovementsIn:   []application.Movement{newMovement("1", "checkin")},
+			ConditionsIn:  []domain.ContractCondition{condition("Checkin", "1.00", "checkin"), condition("CheckinAgain", "1.00", "checkin")},
+			ExpectedIsTie: true,
+		},
+		{
+			Alias:                 `Tie of single activity conditions is broken by tie breakers`,
+			MovementsIn:           []application.Movement{newMovement("1", "checkin")},
+			ConditionsIn:          []domain.ContractCondition{condition("CheckinAgain", "1.00", "checkin"), condition("Checkin", "1.00", "checkin")},
+			OptionsIn:             []application.Option{application.WithTieBreakers(application.TieBreakLowestConditionId())},
+			ExpectedConditionIds:  []string{"Checkin"},
+			ExpectedIsTie:         true,
+			ExpectedIsTieResolved: true,
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			ctx := context.Background()
+
+			actualResult, err := application.MatchMovements(ctx, tCase.MovementsIn, tCase.ConditionsIn, tCase.OptionsIn...)
+
+			if tCase.ExpectedIsTie && !tCase.ExpectedIsTieResolved {
+				assert.ErrorIs(t, err, application.ErrAmbiguousResult)
+				assert.Len(t, actualResult.TiedCombinations, 2)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tCase.ExpectedIsTie, actualResult.IsTie)
+			assert.Equal(t, tCase.ExpectedIsTieResolved, actualResult.IsTieResolved)
+
+			actualConditionIds := []string(nil)
+			for _, match := range actualResult.Bundles {
+				actualConditionIds = append(actualConditionIds, match.ContractCondition.Id)
+			}
+			assert.Equal(t, tCase.ExpectedConditionIds, actualConditionIds)
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func TestMatchMovements_PriorityAndSuppressionOfSingleActivity(t *testing.T) {
+
+	movement := func(id, movementType, vehicleType string) application.Movement {
+		return newMovement(id, movementType, func(mvmt *application.Movement) { mvmt.Vehicle.Type = vehicleType })
+	}
+
+	condition := func(id, vehicleType string, activityTypes ...string) domain.ContractCondition {
+		return newCondition(id, activityTypes, func(cond *domain.ContractCondition) { cond.VehicleType = vehicleType })
+	}
+
+	// Van checkin scores twice as high for the exclusive condition as for the generic one, but then car checkins are left unmatched.
+	vanCheckin := condition("VanCheckin", "van", "checkin")
+	vanCheckin.Exclusive = true
+	vanCheckin.Suppresses = []string{"AnyCheckin"}
+	suppressing := []domain.ContractCondition{vanCheckin, condition("AnyCheckin", "", "checkin")}
+
+	prioritized := condition("Checkin", "car", "checkin")
+	prioritized.Priority = 10
+	bundleAndPrioritized := []domain.ContractCondition{condition("Turnaround", "car", "checkin", "parking"), prioritized}
+
+	// Checkin of any vehicle scores lower than car checkin, but wins by priority.
+	prioritizedAny := condition("AnyCheckin", "", "checkin")
+	prioritizedAny.Priority = 1
+	singlesPrioritized := []domain.ContractCondition{condition("CarCheckin", "car", "checkin"), prioritizedAny}
+
+	testCases := []struct {
+		Alias                string
+		MovementsIn          []application.Movement
+		ConditionsIn         []domain.ContractCondition
+		ExpectedConditionIds []string
+		ExpectedUnmatched    []string
+	}{
+		{
+			Alias:                `Exclusive condition scoring higher wins, car checkin goes first`,
+			MovementsIn:          []application.Movement{movement("1", "checkin", "car"), movement("2", "checkin", "van"), movement("3", "checkin", "van")},
+			ConditionsIn:         suppressing,
+			ExpectedConditionIds: []string{"VanCheckin", "VanCheckin"},
+			ExpectedUnmatched:    []string{"1"},
+		},
+		{
+			Alias:                `Exclusive condition scoring higher wins, car checkin goes last`,
+			MovementsIn:          []application.Movement{movement("2", "checkin", "van"), movement("3", "checkin", "van"), movement("1", "checkin", "car")},
+			ConditionsIn:         suppressing,
+			ExpectedConditionIds: []string{"VanCheckin", "VanCheckin"},
+			ExpectedUnmatched:    []string{"1"},
+		},
+		{
+			Alias:                `Suppressed condition scoring higher wins, van checkin goes first`,
+			MovementsIn:          []application.Movement{movement("1", "checkin", "van"), movement("2", "checkin", "car"), movement("3", "checkin", "car")},
+			ConditionsIn:         suppressing,
+			ExpectedConditionIds: []string{"AnyCheckin", "AnyCheckin", "AnyCheckin"},
+		},
+		{
+			Alias:                `Suppressed condition scoring higher wins, van checkin goes last`,
+			MovementsIn:          []application.Movement{movement("2", "checkin", "car"), movement("3", "checkin", "car"), movement("1", "checkin", "van")},
+			ConditionsIn:         suppressing,
+			ExpectedConditionIds: []string{"AnyCheckin", "AnyCheckin", "AnyCheckin"},
+		},
+		{
+			Alias:                `Single activity condition of higher priority wins over the bundle`,
+			MovementsIn:          []application.Movement{movement("1", "checkin", "car"), movement("2", "parking", "car")},
+			ConditionsIn:         bundleAndPrioritized,
+			ExpectedConditionIds: []string{"Checkin"},
+			ExpectedUnmatched:    []string{"2"},
+		},
+		{
+			Alias:                `Single activity condition of higher priority wins over the one scoring higher`,
+			MovementsIn:          []application.Movement{movement("1", "checkin", "car")},
+			ConditionsIn:         singlesPrioritized,
+			ExpectedConditionIds: []string{"AnyCheckin"},
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			ctx := context.Background()
+
+			actualResult, err := application.MatchMovements(ctx, tCase.MovementsIn, tCase.ConditionsIn)
+
+			assert.NoError(t, err)
+
+			actualConditionIds := []string(nil)
+			for _, match := range actualResult.Bundles {
+				actualConditionIds = append(actualConditionIds, match.ContractCondition.Id)
+			}
+			actualUnmatched := []string(nil)
+			for _, mvmt := range actualResult.Unmatched {
+				actualUnmatched = append(actualUnmatched, mvmt.Id)
+			}
+
+			assert.Equal(t, tCase.ExpectedConditionIds, actualConditionIds)
+			assert.Equal(t, tCase.ExpectedUnmatched, actualUnmatched)
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func TestMatchMovements_SuppressionOfSameId(t *testing.T) {
+
+	movements := []application.Movement{newMovement("1", "checkin"), newMovement("2", "parking")}
+
+	// Conditions without Id are still different conditions, so the exclusive one rules the other out.
+	conditions := []domain.ContractCondition{
+		newCondition("", []string{"checkin"}, func(cond *domain.ContractCondition) {
+			cond.Name = "ExclusiveCheckin"
+			cond.Exclusive = true
+			cond.Priority = 1
+		}),
+		newCondition("", []string{"parking"}, func(cond *domain.ContractCondition) { cond.Name = "Parking" }),
+	}
+
+	ctx := context.Background()
+
+	actualResult, err := application.MatchMovements(ctx, movements, conditions)
+
+	assert.NoError(t, err)
+	if assert.Len(t, actualResult.Bundles, 1) {
+		assert.Equal(t, "ExclusiveCheckin", actualResult.Bundles[0].ContractCondition.Name)
+	}
+	assert.Equal(t, movements[1:], actualResult.Unmatched)
+
+	actualReasons := map[string]application.RejectionReason{}
+	for _, rejection := range actualResult.Rejections {
+		actualReasons[rejection.ContractCondition.Name] = rejection.Reason
+	}
+	assert.Equal(t, map[string]application.RejectionReason{
+		"ExclusiveCheckin": application.RejectionReasonMovementActivityTypeMismatch,
+		"Parking":          application.RejectionReasonSuppressed,
+	}, actualReasons)
+}
+
+func TestMatchMovements_DeadlineOnMixedClasses(t *testing.T) {
+
+	// Movements of six types fit contract conditions of every three of them within their spans, so hardly any two movements
+	// are interchangeable and there are too many ways to match them to go through all of them.
+	// Bundles go first, so the interrupted search still matches most of movements.
+	const movementsCount = 100
+
+	types := []string{"checkin", "wash", "parking", "fuel", "inspection", "checkout"}
+
+	movements := []application.Movement{}
+	for mvmtNo := 0; mvmtNo < movementsCount; mvmtNo++ {
+		movements = append(movements, newMovement(strconv.Itoa(mvmtNo), types[mvmtNo*7%len(types)], func(mvmt *application.Movement) {
+			mvmt.Date = mvmt.Date.Add(time.Duration(mvmtNo*37%600) * time.Minute)
+			mvmt.Vehicle.Id = strconv.Itoa(mvmtNo % 10)
+			if mvmtNo%4 == 0 {
+				mvmt.Option = "express"
+			}
+		}))
+	}
+
+	conditions := []domain.ContractCondition{}
+	for i := range types {
+		for j := i + 1; j < len(types); j++ {
+			for k := j + 1; k < len(types); k++ {
+				condNo := len(conditions)
+				activityTypes := []string{types[i], types[j], types[k]}
+				conditions = append(conditions, newCondition(strings.Join(activityTypes, "-"), activityTypes, func(cond *domain.ContractCondition) {
+					cond.MaxSpan = time.Duration(1+condNo%4) * time.Hour
+					if condNo%2 == 0 {
+						cond.CoherentAttributes = []string{domain.CoherentAttribute_VehicleId}
+					}
+					if condNo%3 == 0 {
+						cond.MovementActivities[0].Option = "express"
+					}
+				}))
+			}
+		}
+	}
+
+	const timeout = 200 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	started := time.Now()
+	_, err := application.MatchMovements(ctx, movements, conditions)
+
+	assert.ErrorIs(t, err, application.ErrPartialResult)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(started), 10*timeout)
+
+	// How far the search gets by the deadline depends on the machine, so the result is checked within the search budget instead.
+	actualResult, err := application.MatchMovements(context.Background(), movements, conditions, application.WithSearchBudget(50000))
+
+	assert.ErrorIs(t, err, application.ErrPartialResult)
+	assert.ErrorIs(t, err, application.ErrSearchBudgetExceeded)
+	assert.Less(t, len(actualResult.Unmatched), movementsCount/10)
+}
+
+// newMixedOptionsInput returns movements of three types and four options, which contract conditions without bundle constraints
+// combine in so many ways, that the search goes through dead ends over and over unless they are memoized.
+func newMixedOptionsInput() ([]application.Movement, []domain.ContractCondition) {
+
+	const movementsCount = 60
+
+	types := []string{"checkin", "parking", "wash"}
+	options := []string{"", "x", "y", "z"}
+
+	movements := []application.Movement{}
+	for mvmtNo := 0; mvmtNo < movementsCount; mvmtNo++ {
+		movements = append(movements, newMovement(strconv.Itoa(mvmtNo), types[mvmtNo%len(types)], func(mvmt *application.Movement) {
+			mvmt.Option = options[mvmtNo%len(options)]
+		}))
+	}
+
+	conditions := []domain.ContractCondition{
+		newCondition("Turnaround", []string{"checkin", "parking"}),
+		newCondition("TurnaroundWash", []string{"checkin", "parking", "wash"}),
+		newCondition("Wash", []string{"wash"}),
+		newCondition("ExpressTurnaround", []string{"checkin", "parking"}, func(cond *domain.ContractCondition) {
+			cond.MovementActivities[0].Option = "x"
+		}),
+	}
+
+	return movements, conditions
+}
+
+func TestMatchMovements_DeadlineOnMixedOptions(t *testing.T) {
+
+	movements, conditions := newMixedOptionsInput()
+
+	const timeout = 300 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	started := time.Now()
+	actualResult, err := application.MatchMovements(ctx, movements, conditions)
+
+	assert.ErrorIs(t, err, application.ErrPartialResult)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(started), 3*timeout)
+	assert.Empty(t, actualResult.Unmatched)
+}
+
+func TestMatchMovements_CancelOnMixedOptions(t *testing.T) {
+
+	movements, conditions := newMixedOptionsInput()
+
+	const timeout = 300 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(timeout, cancel)
+
+	started := time.Now()
+	actualResult, err := application.MatchMovements(ctx, movements, conditions)
+
+	assert.ErrorIs(t, err, application.ErrPartialResult)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(started), 3*timeout)
+	assert.Empty(t, actualResult.Unmatched)
+}
+
+func TestMatchMovements_SearchBudgetOnMixedOptions(t *testing.T) {
+
+	movements, conditions := newMixedOptionsInput()
+
+	// Going through all combinations takes seconds, while the budget is used up in a fraction of that.
+	const timeLimit = 2 * time.Second
+
+	started := time.Now()
+	actualResult, err := application.MatchMovements(context.Background(), movements, conditions, application.WithSearchBudget(100000))
+
+	assert.ErrorIs(t, err, application.ErrPartialResult)
+	assert.ErrorIs(t, err, application.ErrSearchBudgetExceeded)
+	assert.Less(t, time.Since(started), timeLimit)
+	assert.Empty(t, actualResult.Unmatched)
+}
+
+func TestMatchMovements_AdditiveObjectivesOnManyMovements(t *testing.T) {
+
+	// Every checkin could go either to the bundle or to the single activity condition,
+	// so there are too many combinations to go through all of them.
+	const pairsCount = 200
+
+	contractor := "987654"
+	movements := []application.Movement{}
+
+	for pairNo := 0; pairNo < pairsCount; pairNo++ {
+		for movementNo, movementType := range []string{"checkin", "parking"} {
+			movements = append(movements, application.Movement{
+				Id:       strconv.Itoa(pairNo) + "-" + movementType,
+				Type:     movementType,
+				Date:     time.Date(2018, 01, 31, 0, 2*pairNo+movementNo, 0, 0, time.UTC),
+				Branch:   application.Branch{Id: "6"},
+				Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+				User:     application.User{Contractor: &contractor, Id: "TheUserId"},
+				Vehicle:  application.Vehicle{Type: "car", Id: strconv.Itoa(pairNo)},
+			})
+		}
+	}
+
+	condition := func(id string, activities ...domain.MovementActivity) domain.ContractCondition {
+		return domain.ContractCondition{
+			Id:                   id,
+			Name:                 id,
+			WorkflowType:         "turnaround",
+			WorkflowFactor:       "standard",
+			VehicleType:          "car",
+			BranchIdentifier:     "6",
+			ContractorIdentifier: contractor,
+			CoherentAttributes:   []string{domain.CoherentAttribute_VehicleId},
+			MovementActivities:   activities,
+		}
+	}
+
+	conditions := []domain.ContractCondition{
+		condition("Turnaround", domain.MovementActivity{Type: "checkin"}, domain.MovementActivity{Type: "parking"}),
+		condition("Checkin", domain.MovementActivity{Type: "checkin"}),
+	}
+
+	testCases := []struct {
+		Alias       string
+		ObjectiveIn application.Objective
+	}{
+		{
+			Alias:       `Matched movements`,
+			ObjectiveIn: application.ObjectiveMatchedMovements(),
+		},
+		{
+			Alias: `Custom objective valuing matches one by one`,
+			ObjectiveIn: application.MatchObjective(func(match application.Match) *big.Rat {
+				if match.ContractCondition == nil {
+					return new(big.Rat)
+				}
+				return big.NewRat(int64(len(match.Movements)), 1)
+			}),
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			actualResult, err := application.MatchMovements(ctx, movements, conditions, application.WithObjectives(tCase.ObjectiveIn))
+
+			assert.NoError(t, err)
+			assert.Len(t, actualResult.Bundles, pairsCount)
+			for _, match := range actualResult.Bundles {
+				assert.Equal(t, "Turnaround", match.ContractCondition.Id)
+			}
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+// BenchmarkMatchMovements matches a day of a large branch: thousands of movements of vehicles of dozens of types
+// against hundreds of contract conditions.
+func BenchmarkMatchMovements(b *testing.B) {
+
+	const (
+		vehiclesCount     = 1000
+		vehicleTypesCount = 50
+	)
+
+	movements := []application.Movement{}
+	for vehicleNo := 0; vehicleNo < vehiclesCount; vehicleNo++ {
+
+		// Every third vehicle gets washed, and every tenth one is not parked yet.
+		movementTypes := []string{"checkin", "parking"}
+		switch {
+		case vehicleNo%10 == 0:
+			movementTypes = []string{"checkin"}
+		case vehicleNo%3 == 0:
+			movementTypes = []string{"checkin", "wash", "parking"}
+		}
+
+		for movementNo, movementType := range movementTypes {
+			movements = append(movements, newMovement(strconv.Itoa(vehicleNo)+"-"+movementType, movementType, func(mvmt *application.Movement) {
+				mvmt.Date = time.Date(2018, 01, 31, 0, vehicleNo+30*movementNo, 0, 0, time.UTC)
+				mvmt.Workflow.Id = strconv.Itoa(vehicleNo)
+				mvmt.Vehicle = application.Vehicle{Type: "type-" + strconv.Itoa(vehicleNo%vehicleTypesCount), Id: strconv.Itoa(vehicleNo)}
+			}))
+		}
+	}
+
+	conditions := []domain.ContractCondition{}
+	for typeNo := 0; typeNo < vehicleTypesCount; typeNo++ {
+
+		vehicleType := "type-" + strconv.Itoa(typeNo)
+
+		for _, activityTypes := range [][]string{{"checkin", "wash", "parking"}, {"checkin", "parking"}, {"checkin"}, {"parking"}} {
+			conditions = append(conditions, newCondition(vehicleType+"-"+strings.Join(activityTypes, "-"), activityTypes, func(cond *domain.ContractCondition) {
+				cond.VehicleType = vehicleType
+				cond.MaxSpan = 4 * time.Hour
+				cond.CoherentAttributes = []string{domain.CoherentAttribute_VehicleId}
+			}))
+		}
+	}
+
+	ctx := context.Background()
+
+	b.ResetTimer()
+
+	for run := 0; run < b.N; run++ {
+		if _, err := application.MatchMovements(ctx, movements, conditions); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkMatchMovements_CombinableMovements matches a day of a large branch, where contract conditions check no coherent attributes,
+// so movements of vehicles of the same type combine with each other within the span of conditions.
+func BenchmarkMatchMovements_CombinableMovements(b *testing.B) {
+
+	const (
+		vehiclesCount     = 1000
+		vehicleTypesCount = 50
+	)
+
+	start := time.Date(2018, 01, 31, 0, 0, 0, 0, time.UTC)
+
+	movements := []application.Movement{}
+	for vehicleNo := 0; vehicleNo < vehiclesCount; vehicleNo++ {
+
+		// Every third vehicle gets washed, and every tenth one is not parked yet.
+		movementTypes := []string{"checkin", "parking"}
+		switch {
+		case vehicleNo%10 == 0:
+			movementTypes = []string{"checkin"}
+		case vehicleNo%3 == 0:
+			movementTypes = []string{"checkin", "wash", "parking"}
+		}
+
+		// Vehicles come in evenly over the day, so about two dozen movements of the same type fit the span of a condition.
+		checkedIn := start.Add(time.Duration(vehicleNo) * 24 * time.Hour / vehiclesCount)
+
+		for movementNo, movementType := range movementTypes {
+			movements = append(movements, newMovement(strconv.Itoa(vehicleNo)+"-"+movementType, movementType, func(mvmt *application.Movement) {
+				mvmt.Date = checkedIn.Add(time.Duration(30*movementNo) * time.Minute)
+				mvmt.Workflow.Id = strconv.Itoa(vehicleNo)
+				mvmt.Vehicle = application.Vehicle{Type: "type-" + strconv.Itoa(vehicleNo%vehicleTypesCount), Id: strconv.Itoa(vehicleNo)}
+			}))
+		}
+	}
+
+	conditions := []domain.ContractCondition{}
+	for typeNo := 0; typeNo < vehicleTypesCount; typeNo++ {
+
+		vehicleType := "type-" + strconv.Itoa(typeNo)
+
+		for _, activityTypes := range [][]string{{"checkin", "wash", "parking"}, {"checkin", "parking"}, {"checkin"}, {"parking"}} {
+			conditions = append(conditions, newCondition(vehicleType+"-"+strings.Join(activityTypes, "-"), activityTypes, func(cond *domain.ContractCondition) {
+				cond.VehicleType = vehicleType
+				cond.MaxSpan = time.Hour
+			}))
+		}
+	}
+
+	ctx := context.Background()
+
+	b.ResetTimer()
+
+	for run := 0; run < b.N; run++ {
+		if _, err := application.MatchMovements(ctx, movements, conditions); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package application
+
+import "github.com/ivan-kostko/nrute-matches/domain"
+
+// activityCardinality returns the minimal and maximal number of movements to be matched to the movement activity.
+func activityCardinality(ccma domain.MovementActivity) (int, int) {
+
+	min := 1
+	if ccma.Min > 0 {
+		min = ccma.Min
+	}
+
+	max := ccma.Max
+	if max < min {
+		max = min
+	}
+
+	if ccma.Optional {
+		min = 0
+	}
+
+	return min, max
+}
+
+// isBundle tells whether the contract condition could match more than one movement.
+func isBundle(cond *domain.ContractCondition) bool {
+	return maxMovements(cond) > 1
+}
+
+// maxMovements returns the number of movements the contract condition could match at most.
+func maxMovements(cond *domain.ContractCondition) int {
+
+	result := 0
+	for _, ccma := range cond.MovementActivities {
+		_, max := activityCardinality(ccma)
+		result += max
+	}
+
+	return result
+}
+
+// minMovements returns the number of movements the contract condition needs at least to be fulfilled.
+// A condition needs at least one movement, even if all its activities are optional.
+func minMovements(cond *domain.ContractCondition) int {
+
+	result := 0
+	for _, ccma := range cond.MovementActivities {
+		min, _ := activityCardinality(ccma)
+		result += min
+	}
+
+	if result == 0 {
+		result = 1
+	}
+
+	return result
+}
@@ -0,0 +1,86 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ivan-kostko/nrute-matches/domain"
+)
+
+var (
+	// ErrInvalidCondition is returned when a contract condition can not be matched by its definition.
+	ErrInvalidCondition = errors.New("invalid contract condition")
+	// ErrDuplicateCondition is reported by Validator when more than one contract condition has the same Id.
+	ErrDuplicateCondition = errors.New("duplicate contract condition")
+	// ErrInvalidMovement is returned when a movement is malformed.
+	ErrInvalidMovement = errors.New("invalid movement")
+	// ErrDuplicateMovement is returned when more than one movement has the same Id.
+	ErrDuplicateMovement = errors.New("duplicate movement")
+	// ErrPartialResult is returned along with the best result found so far, when the search was interrupted.
+	ErrPartialResult = errors.New("matching was interrupted, result is partial")
+	// ErrSearchBudgetExceeded is returned along with ErrPartialResult, when the search took more steps than allowed.
+	ErrSearchBudgetExceeded = errors.New("search budget exceeded")
+	// ErrAmbiguousResult is returned when more than one combination has the best score and the tie could not be resolved.
+	ErrAmbiguousResult = errors.New("ambiguous result, more than one combination has the best score")
+	// ErrInvalidTaxonomy is returned when a vehicle taxonomy can not be walked from child to parent types.
+	ErrInvalidTaxonomy = errors.New("invalid vehicle taxonomy")
+	// ErrInvalidPrice is returned when a contract condition price model has malformed amount.
+	ErrInvalidPrice = errors.New("invalid price")
+)
+
+// checkInput returns all problems of input which make matching pointless.
+// Price objectives sum prices of matches up, so along with them contract conditions have to be priced in a single currency.
+func checkInput(movements []Movement, conds []domain.ContractCondition, objectives []Objective) error {
+
+	errs := []error{}
+
+	for condNo, cond := range conds {
+		if len(cond.MovementActivities) == 0 {
+			errs = append(errs, fmt.Errorf("%w: contract condition #%d (Id %q) has no movement activities", ErrInvalidCondition, condNo, cond.Id))
+		}
+		if err := checkPriceModel(&cond); err != nil {
+			errs = append(errs, fmt.Errorf("%w: %w", ErrInvalidCondition, err))
+		}
+		// Malformed patterns never match, which would look as if no movement fits the condition.
+		if !isValidPattern(cond.VehicleType) {
+			errs = append(errs, fmt.Errorf("%w: contract condition #%d (Id %q) has malformed VehicleType pattern %q", ErrInvalidCondition, condNo, cond.Id, cond.VehicleType))
+		}
+		if !isValidPattern(cond.WorkflowFactor) {
+			errs = append(errs, fmt.Errorf("%w: contract condition #%d (Id %q) has malformed WorkflowFactor pattern %q", ErrInvalidCondition, condNo, cond.Id, cond.WorkflowFactor))
+		}
+		for maNo, ccma := range cond.MovementActivities {
+			if !isValidPattern(ccma.Option) {
+				errs = append(errs, fmt.Errorf("%w: contract condition #%d (Id %q) has malformed MovementActivities[%d].Option pattern %q", ErrInvalidCondition, condNo, cond.Id, maNo, ccma.Option))
+			}
+		}
+	}
+
+	if hasPriceObjective(objectives) {
+		currencies := map[string]bool{}
+		for _, cond := range conds {
+			if isPriced(cond.Price) {
+				currencies[cond.Price.Currency] = true
+			}
+		}
+		if len(currencies) > 1 {
+			errs = append(errs, fmt.Errorf("%w: %w: contract conditions are priced in %d currencies, while price objectives sum prices up", ErrInvalidCondition, ErrInvalidPrice, len(currencies)))
+		}
+	}
+
+	seenMovementIds := map[string]bool{}
+
+	for _, mvmt := range movements {
+
+		if seenMovementIds[mvmt.Id] {
+			errs = append(errs, fmt.Errorf("%w: movement Id %q", ErrDuplicateMovement, mvmt.Id))
+		}
+		seenMovementIds[mvmt.Id] = true
+
+		// Movements without contractor are expected to have nil contractor, so empty one is most likely broken import.
+		if mvmt.User.Contractor != nil && *mvmt.User.Contractor == "" {
+			errs = append(errs, fmt.Errorf("%w: movement %q has empty contractor instead of nil", ErrInvalidMovement, mvmt.Id))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,59 @@
+package application_test
+
+import (
+	"time"
+
+	"github.com/ivan-kostko/nrute-matches/application"
+	"github.com/ivan-kostko/nrute-matches/domain"
+)
+
+// fixtureContractor is the contractor of movements and contract conditions built by fixtures.
+const fixtureContractor = "987654"
+
+// newMovement returns a movement of movementType by the fixture contractor at the fixture branch within the fixture workflow,
+// changed by modify functions in their order.
+func newMovement(id, movementType string, modify ...func(mvmt *application.Movement)) application.Movement {
+
+	contractor := fixtureContractor
+
+	mvmt := application.Movement{
+		Id:       id,
+		Type:     movementType,
+		Date:     time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+		Branch:   application.Branch{Id: "6"},
+		Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+		User:     application.User{Contractor: &contractor, Id: "TheUserId"},
+		Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+	}
+
+	for _, fn := range modify {
+		fn(&mvmt)
+	}
+
+	return mvmt
+}
+
+// newCondition returns a contract condition of the fixture contractor, branch and workflow fitting cars
+// with movement activities of activityTypes, changed by modify functions in their order.
+func newCondition(id string, activityTypes []string, modify ...func(cond *domain.ContractCondition)) domain.ContractCondition {
+
+	cond := domain.ContractCondition{
+		Id:                   id,
+		Name:                 id,
+		WorkflowType:         "turnaround",
+		WorkflowFactor:       "standard",
+		VehicleType:          "car",
+		BranchIdentifier:     "6",
+		ContractorIdentifier: fixtureContractor,
+	}
+
+	for _, activityType := range activityTypes {
+		cond.MovementActivities = append(cond.MovementActivities, domain.MovementActivity{Type: activityType})
+	}
+
+	for _, fn := range modify {
+		fn(&cond)
+	}
+
+	return cond
+}
@@ -1,16 +1,19 @@
-/*
-	This file contains log adapter in order to make debugging a bit easier.
-	Feel free to replace it with something better.
-*/
-
 package application
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	loglib "log"
+	"log/slog"
+	"sort"
+	"strconv"
+
+	"github.com/ivan-kostko/nrute-matches/domain"
 )
 
+// Log is the logger used by matching.
+// Fields added by WithFields go along with every further message.
+// Args of messages are either strings, which are joined into the message, or slog.Attr, which go along with it.
 type Log interface {
 	WithFields(map[string]interface{}) Log
 	Warn(args ...interface{})
@@ -18,112 +21,209 @@ type Log interface {
 	Debug(args ...interface{})
 }
 
-type log struct {
-	fields map[string]interface{}
+// NewLog returns Log writing to logger, or to slog.Default() if logger is nil.
+// Messages are built only if logger is enabled for their level, and fields are passed to logger only along with them.
+func NewLog(logger *slog.Logger) Log {
+	return newContextLog(context.Background(), logger)
 }
 
-func (l *log) WithFields(fields map[string]interface{}) Log {
+// newContextLog returns Log writing to logger, or to slog.Default() if logger is nil, with ctx passed to its handler.
+func newContextLog(ctx context.Context, logger *slog.Logger) Log {
 
-	mergedFields := make(map[string]interface{})
-
-	for k, v := range l.fields {
-		mergedFields[k] = v
+	if logger == nil {
+		logger = slog.Default()
 	}
 
-	for k, v := range fields {
-		mergedFields[k] = v
-	}
+	return &slogLog{ctx: ctx, logger: logger}
+}
 
-	return &log{
-		fields: mergedFields,
-	}
+// loggerContextKey is the key of the logger in context.
+type loggerContextKey struct{}
+
+// ContextWithLogger returns a copy of ctx carrying logger, which matching writes to unless WithLogger is given,
+// e.g. the logger of the request with its request Id attached.
+func ContextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
 }
 
-func (l *log) printf(lvl string, args ...interface{}) {
+// LoggerFromContext returns the logger carried by ctx, or nil if there is none.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	logger, _ := ctx.Value(loggerContextKey{}).(*slog.Logger)
+	return logger
+}
 
-	stringArgs := ""
-	for _, arg := range args {
-		switch t := arg.(type) {
-		case string:
-			stringArgs += t
-		case []Match:
-			stringArgs += fmt.Sprintf("%#v", goStringableCombination(t))
-		case [][]Match:
-			stringArgs += fmt.Sprintf("%#v", goStringableCombinationSet(t))
-		case []Movement:
-			stringArgs += fmt.Sprintf("%#v", goStringableMovementSet(t))
-		default:
-			stringArgs += fmt.Sprintf("%#v", t)
+// slogLog adapts *slog.Logger to Log.
+type slogLog struct {
+	// ctx is passed to the handler, so it could pick up values of ctx, e.g. trace Id.
+	ctx    context.Context
+	logger *slog.Logger
+	// fields are kept as attributes instead of going to logger.With,
+	// as handlers format them right away, while most of messages are filtered out by level.
+	fields []slog.Attr
+}
+
+func (l *slogLog) WithFields(fields map[string]interface{}) Log {
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
+	merged := make([]slog.Attr, 0, len(l.fields)+len(fields))
+	for _, field := range l.fields {
+		if _, ok := fields[field.Key]; !ok {
+			merged = append(merged, field)
 		}
 	}
-	loglib.Printf(" [%s] fields: %+v \r\n message: %s\r\n ", lvl, l.fields, stringArgs)
+	for _, key := range keys {
+		merged = append(merged, slog.Any(key, fields[key]))
+	}
+
+	return &slogLog{ctx: l.ctx, logger: l.logger, fields: merged}
 }
 
-func (l *log) Warn(args ...interface{}) {
-	lvl := "WARN"
-	l.printf(lvl, args...)
+// isDebugEnabled tells whether logger writes Debug messages, so hot paths could skip building them altogether.
+// Matching movements to activities, searching for assignments and explaining rejections repeat for every pair of movement
+// and contract condition, so they check it once and build debug messages only if they are written.
+// Logs other than the ones of the package are taken as writing them.
+func isDebugEnabled(logger Log) bool {
+	l, ok := logger.(*slogLog)
+	return !ok || l.logger.Enabled(l.ctx, slog.LevelDebug)
 }
-func (l *log) Info(args ...interface{}) {
-	lvl := "INFO"
-	l.printf(lvl, args...)
+
+func (l *slogLog) Warn(args ...interface{}) {
+	l.log(slog.LevelWarn, args...)
 }
-func (l *log) Debug(args ...interface{}) {
-	lvl := "DEBU"
-	l.printf(lvl, args...)
+
+func (l *slogLog) Info(args ...interface{}) {
+	l.log(slog.LevelInfo, args...)
 }
 
-func (mvmt Movement) GoString() string {
-	b, _ := json.Marshal(mvmt)
-	return string(b)
+func (l *slogLog) Debug(args ...interface{}) {
+	l.log(slog.LevelDebug, args...)
 }
 
-func (match Match) GoString() string {
+// log builds the message out of args and passes it to logger along with fields, if logger is enabled for level.
+// Args other than strings and slog.Attr go along with the message as attributes keyed by their position.
+func (l *slogLog) log(level slog.Level, args ...interface{}) {
 
-	result := "\r\nMovements:\r\n"
+	if !l.logger.Enabled(l.ctx, level) {
+		return
+	}
 
-	for mvNo, mvmt := range match.Movements {
-		result += fmt.Sprintf("%d : %#v\r\n", mvNo, mvmt)
+	message := ""
+	attrs := append(make([]slog.Attr, 0, len(l.fields)+len(args)), l.fields...)
+
+	for argNo, arg := range args {
+		switch t := arg.(type) {
+		case string:
+			message += t
+		case slog.Attr:
+			attrs = append(attrs, t)
+		default:
+			attrs = append(attrs, slog.Any("arg"+strconv.Itoa(argNo), t))
+		}
 	}
-	result += fmt.Sprintf("ContractCondition:\r\n%#v\r\nScore: %d\r\n", match.ContractCondition, match.Score)
-	return result
+
+	l.logger.LogAttrs(l.ctx, level, message, attrs...)
 }
 
-type goStringableCombination []Match
+// LogValue implements slog.LogValuer.
+func (mvmt Movement) LogValue() slog.Value {
+
+	contractor := ""
+	if mvmt.User.Contractor != nil {
+		contractor = *mvmt.User.Contractor
+	}
 
-func (combi goStringableCombination) GoString() string {
+	return slog.GroupValue(
+		slog.String("id", mvmt.Id),
+		slog.String("type", mvmt.Type),
+		slog.String("option", mvmt.Option),
+		slog.Time("date", mvmt.Date),
+		slog.String("branch_id", mvmt.Branch.Id),
+		slog.String("workflow_id", mvmt.Workflow.Id),
+		slog.String("workflow_type", mvmt.Workflow.Type),
+		slog.String("workflow_factor", mvmt.Workflow.Factor),
+		slog.String("user_id", mvmt.User.Id),
+		slog.String("contractor", contractor),
+		slog.String("vehicle_id", mvmt.Vehicle.Id),
+		slog.String("vehicle_type", mvmt.Vehicle.Type),
+	)
+}
 
-	result := "\r\nMatch Combination:\r\n"
+// LogValue implements slog.LogValuer.
+// Unmatched movements are represented by a Match without contract condition, which is logged with empty contract_condition_id.
+func (match Match) LogValue() slog.Value {
 
-	for mNo, m := range combi {
-		result += fmt.Sprintf("%d : %#v\r\n", mNo, m)
+	condId := ""
+	if match.ContractCondition != nil {
+		condId = match.ContractCondition.Id
 	}
 
-	return result
+	return slog.GroupValue(
+		slog.String("contract_condition_id", condId),
+		slog.Int("score", match.Score),
+		slog.Any("movements", movementSet(match.Movements)),
+	)
 }
 
-type goStringableCombinationSet [][]Match
+// movementSet represents movements in logs.
+type movementSet []Movement
 
-func (combiSet goStringableCombinationSet) GoString() string {
+// LogValue implements slog.LogValuer.
+func (mvmts movementSet) LogValue() slog.Value {
+	return indexedGroupValue(len(mvmts), func(mvmtNo int) slog.Value { return mvmts[mvmtNo].LogValue() })
+}
 
-	result := "\r\nCombination Set:\r\n"
+// combination represents matches of a combination in logs.
+type combination []Match
 
-	for mNo, m := range combiSet {
-		result += fmt.Sprintf("%d : %#v\r\n", mNo, goStringableCombination(m))
-	}
+// LogValue implements slog.LogValuer.
+func (c combination) LogValue() slog.Value {
+	return indexedGroupValue(len(c), func(matchNo int) slog.Value { return c[matchNo].LogValue() })
+}
 
-	return result
+// combinationSet represents combinations in logs.
+type combinationSet [][]Match
+
+// LogValue implements slog.LogValuer.
+func (cs combinationSet) LogValue() slog.Value {
+	return indexedGroupValue(len(cs), func(combinationNo int) slog.Value { return combination(cs[combinationNo]).LogValue() })
 }
 
-type goStringableMovementSet []Movement
+// conditionSet represents contract conditions in logs by their Ids.
+type conditionSet []*domain.ContractCondition
 
-func (mvmtSet goStringableMovementSet) GoString() string {
+// LogValue implements slog.LogValuer.
+func (conds conditionSet) LogValue() slog.Value {
+	return indexedGroupValue(len(conds), func(condNo int) slog.Value { return slog.StringValue(conds[condNo].Id) })
+}
 
-	result := "\r\nMovement Set:\r\n"
+// indexedGroupValue returns the group of n values keyed by their positions.
+func indexedGroupValue(n int, value func(no int) slog.Value) slog.Value {
 
-	for mNo, m := range mvmtSet {
-		result += fmt.Sprintf("%d : %#v\r\n", mNo, m)
+	attrs := make([]slog.Attr, n)
+	for no := range attrs {
+		attrs[no] = slog.Attr{Key: strconv.Itoa(no), Value: value(no)}
 	}
 
+	return slog.GroupValue(attrs...)
+}
+
+func (mvmt Movement) GoString() string {
+	b, _ := json.Marshal(mvmt)
+	return string(b)
+}
+
+func (match Match) GoString() string {
+
+	result := "\r\nMovements:\r\n"
+
+	for mvNo, mvmt := range match.Movements {
+		result += fmt.Sprintf("%d : %#v\r\n", mvNo, mvmt)
+	}
+	result += fmt.Sprintf("ContractCondition:\r\n%#v\r\nScore: %d\r\n", match.ContractCondition, match.Score)
 	return result
 }
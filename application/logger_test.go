@@ -0,0 +1,251 @@
+package application_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ivan-kostko/nrute-matches/application"
+	"github.com/ivan-kostko/nrute-matches/domain"
+)
+
+// TestMain discards logs written to slog.Default(), so they do not flood test output.
+// Tests checking logs pass their own loggers.
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1})))
+	os.Exit(m.Run())
+}
+
+// countingValuer counts how many times it was asked for its log value.
+type countingValuer struct {
+	count *int
+}
+
+func (v countingValuer) LogValue() slog.Value {
+	*v.count++
+	return slog.StringValue("counted")
+}
+
+func TestNewLog(t *testing.T) {
+
+	testCases := []struct {
+		Alias           string
+		LevelIn         slog.Level
+		LogFn           func(logger application.Log, args ...interface{})
+		ExpectedWritten bool
+	}{
+		{
+			Alias:           `Debug is filtered out by Info level`,
+			LevelIn:         slog.LevelInfo,
+			LogFn:           application.Log.Debug,
+			ExpectedWritten: false,
+		},
+		{
+			Alias:           `Debug is written at Debug level`,
+			LevelIn:         slog.LevelDebug,
+			LogFn:           application.Log.Debug,
+			ExpectedWritten: true,
+		},
+		{
+			Alias:           `Info is written at Info level`,
+			LevelIn:         slog.LevelInfo,
+			LogFn:           application.Log.Info,
+			ExpectedWritten: true,
+		},
+		{
+			Alias:           `Info is filtered out by Warn level`,
+			LevelIn:         slog.LevelWarn,
+			LogFn:           application.Log.Info,
+			ExpectedWritten: false,
+		},
+		{
+			Alias:           `Warn is written at Warn level`,
+			LevelIn:         slog.LevelWarn,
+			LogFn:           application.Log.Warn,
+			ExpectedWritten: true,
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			buf := &bytes.Buffer{}
+			logger := application.NewLog(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: tCase.LevelIn})))
+
+			count := 0
+			logger = logger.WithFields(map[string]interface{}{"logger": "Test", "request_id": "42"}).WithFields(map[string]interface{}{"logger": "Nested"})
+
+			tCase.LogFn(logger, "Matching ", "movements", slog.Any("valuer", countingValuer{count: &count}))
+
+			if !tCase.ExpectedWritten {
+				assert.Empty(t, buf.String())
+				assert.Equal(t, 0, count, "log value should not be resolved for filtered out message")
+				return
+			}
+
+			actual := map[string]interface{}{}
+			assert.NoError(t, json.Unmarshal(buf.Bytes(), &actual))
+			assert.Equal(t, "Matching movements", actual["msg"])
+			assert.Equal(t, "Nested", actual["logger"])
+			assert.Equal(t, "42", actual["request_id"])
+			assert.Equal(t, "counted", actual["valuer"])
+			assert.Equal(t, 1, count)
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func TestMatch_LogValue(t *testing.T) {
+
+	contractor := "987654"
+
+	match := application.Match{
+		Movements: []application.Movement{
+			{
+				Id:       "132456",
+				Type:     "checkin",
+				Date:     time.Date(2018, 01, 31, 16, 59, 59, 0, time.UTC),
+				Branch:   application.Branch{Id: "6"},
+				Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+				User:     application.User{Contractor: &contractor, Id: "TheUserId"},
+				Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+			},
+		},
+		ContractCondition: &domain.ContractCondition{Id: "Turnaround"},
+		Score:             6,
+	}
+
+	buf := &bytes.Buffer{}
+	logger := application.NewLog(slog.New(slog.NewJSONHandler(buf, nil)))
+
+	logger.Info("Matched", slog.Any("match", match))
+
+	actual := struct {
+		Match struct {
+			ContractConditionId string `json:"contract_condition_id"`
+			Score               int    `json:"score"`
+			Movements           map[string]map[string]interface{}
+		} `json:"match"`
+	}{}
+
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &actual))
+	assert.Equal(t, "Turnaround", actual.Match.ContractConditionId)
+	assert.Equal(t, 6, actual.Match.Score)
+	assert.Equal(t, "132456", actual.Match.Movements["0"]["id"])
+	assert.Equal(t, "987654", actual.Match.Movements["0"]["contractor"])
+	assert.Equal(t, "2018-01-31T16:59:59Z", actual.Match.Movements["0"]["date"])
+	// Coherent attributes are logged to tell why movements were not bundled together.
+	assert.Equal(t, "TheVehicleId", actual.Match.Movements["0"]["vehicle_id"])
+	assert.Equal(t, "12314654", actual.Match.Movements["0"]["workflow_id"])
+	assert.Equal(t, "TheUserId", actual.Match.Movements["0"]["user_id"])
+}
+
+func TestMatchMovements_Logger(t *testing.T) {
+
+	contractor := "987654"
+
+	movements := []application.Movement{
+		{
+			Id:       "132456",
+			Type:     "checkin",
+			Date:     time.Date(2018, 01, 31, 16, 59, 59, 0, time.UTC),
+			Branch:   application.Branch{Id: "6"},
+			Workflow: application.Workflow{Id: "12314654", Type: "turnaround", Factor: "standard"},
+			User:     application.User{Contractor: &contractor, Id: "TheUserId"},
+			Vehicle:  application.Vehicle{Type: "car", Id: "TheVehicleId"},
+		},
+	}
+
+	conditions := []domain.ContractCondition{
+		{
+			Id:                   "Checkin",
+			Name:                 "Checkin",
+			WorkflowType:         "turnaround",
+			WorkflowFactor:       "standard",
+			VehicleType:          "car",
+			BranchIdentifier:     "6",
+			ContractorIdentifier: contractor,
+			MovementActivities:   []domain.MovementActivity{{Type: "checkin"}},
+		},
+	}
+
+	// newLogger returns the logger writing JSON lines with request_id into buf.
+	newLogger := func(buf *bytes.Buffer, requestId string) *slog.Logger {
+		return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})).With("request_id", requestId)
+	}
+
+	testCases := []struct {
+		Alias             string
+		WithLoggerIn      bool
+		ContextLoggerIn   bool
+		ExpectedRequestId string
+	}{
+		{
+			Alias:             `Logger given by option`,
+			WithLoggerIn:      true,
+			ContextLoggerIn:   false,
+			ExpectedRequestId: "option",
+		},
+		{
+			Alias:             `Logger carried by context`,
+			WithLoggerIn:      false,
+			ContextLoggerIn:   true,
+			ExpectedRequestId: "context",
+		},
+		{
+			Alias:             `Logger given by option goes before the one carried by context`,
+			WithLoggerIn:      true,
+			ContextLoggerIn:   true,
+			ExpectedRequestId: "option",
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			buf := &bytes.Buffer{}
+			ctx := context.Background()
+			opts := []application.Option{}
+
+			if tCase.WithLoggerIn {
+				opts = append(opts, application.WithLogger(newLogger(buf, "option")))
+			}
+			if tCase.ContextLoggerIn {
+				ctx = application.ContextWithLogger(ctx, newLogger(buf, "context"))
+			}
+
+			_, err := application.MatchMovements(ctx, movements, conditions, opts...)
+			assert.NoError(t, err)
+
+			lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+			assert.NotEmpty(t, lines)
+
+			for _, line := range lines {
+				actual := map[string]interface{}{}
+				assert.NoError(t, json.Unmarshal(line, &actual))
+				assert.Equal(t, tCase.ExpectedRequestId, actual["request_id"])
+				assert.Equal(t, "MatchMovements", actual["logger"])
+			}
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func TestLoggerFromContext(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	assert.Nil(t, application.LoggerFromContext(context.Background()))
+	assert.Same(t, logger, application.LoggerFromContext(application.ContextWithLogger(context.Background(), logger)))
+}
@@ -0,0 +1,243 @@
+package application
+
+import "math/big"
+
+// Objective evaluates a combination of matches, the higher value being the better one.
+// The combination includes unmatched movements as a Match without ContractCondition.
+// Values are compared exactly, so they are rational numbers.
+type Objective interface {
+	Evaluate(combination []Match) *big.Rat
+}
+
+// ObjectiveFunc adapts a function evaluating a combination as a whole to Objective.
+// Such combinations could be compared as a whole only, so matching goes through every one of them.
+type ObjectiveFunc func(combination []Match) *big.Rat
+
+// Evaluate implements Objective.
+func (f ObjectiveFunc) Evaluate(combination []Match) *big.Rat {
+	return f(combination)
+}
+
+// MatchObjective is an Objective valuing a combination by the sum of values of its matches.
+// Unmatched movements have to be valued as the sum of values of each of them alone, and movements differing by Id only the same.
+// Combinations are ranked match by match then, so matching skips ones which could not beat the best found so far.
+type MatchObjective func(match Match) *big.Rat
+
+// Evaluate implements Objective.
+func (f MatchObjective) Evaluate(combination []Match) *big.Rat {
+
+	total := new(big.Rat)
+	for _, match := range combination {
+		total.Add(total, f(match))
+	}
+
+	return total
+}
+
+// conditionObjective is a MatchObjective which tells movements apart only by properties checked by contract conditions.
+type conditionObjective struct {
+	MatchObjective
+	// isPrice tells whether matches are valued by their price, which sums up within a single currency only.
+	isPrice bool
+}
+
+// ObjectiveScore maximizes the total score of matches.
+func ObjectiveScore() Objective {
+	return conditionObjective{MatchObjective: func(match Match) *big.Rat {
+		return new(big.Rat).SetInt64(int64(match.Score))
+	}}
+}
+
+// ObjectiveMatchedMovements maximizes the number of movements matched to contract conditions.
+func ObjectiveMatchedMovements() Objective {
+	return conditionObjective{MatchObjective: func(match Match) *big.Rat {
+		if match.ContractCondition == nil {
+			return new(big.Rat)
+		}
+		return new(big.Rat).SetInt64(int64(len(match.Movements)))
+	}}
+}
+
+// ObjectiveFewerUnmatched minimizes the number of movements left unmatched.
+func ObjectiveFewerUnmatched() Objective {
+	return conditionObjective{MatchObjective: func(match Match) *big.Rat {
+		if match.ContractCondition != nil {
+			return new(big.Rat)
+		}
+		return new(big.Rat).SetInt64(int64(-len(match.Movements)))
+	}}
+}
+
+// ObjectiveRevenue maximizes the total price of matches, as priced by PriceMatches.
+// Amounts of different currencies could not be summed up, so MatchMovements rejects contract conditions priced in more than one.
+func ObjectiveRevenue() Objective {
+	return conditionObjective{MatchObjective: matchPrice, isPrice: true}
+}
+
+// ObjectiveCustomerCost minimizes the total price of matches, as priced by PriceMatches.
+// Matching never leaves a movement unmatched if it could still be matched, so the minimum is taken only over combinations
+// matching every movement they can, rather than leaving priced movements unmatched for free.
+// Amounts of different currencies could not be summed up, so MatchMovements rejects contract conditions priced in more than one.
+func ObjectiveCustomerCost() Objective {
+	return conditionObjective{MatchObjective: func(match Match) *big.Rat {
+		price := matchPrice(match)
+		return price.Neg(price)
+	}, isPrice: true}
+}
+
+// matchPrice returns the price of a match to contract condition, or zero for unmatched movements.
+// Price models of MatchMovements input are checked upfront, so a malformed one could only come unchecked and counts as zero.
+func matchPrice(match Match) *big.Rat {
+
+	if match.ContractCondition == nil {
+		return new(big.Rat)
+	}
+
+	line, err := priceMatch(match)
+	if err != nil {
+		return new(big.Rat)
+	}
+
+	return line.Amount
+}
+
+// hasPriceObjective tells whether some of objectives values matches by their price.
+func hasPriceObjective(objectives []Objective) bool {
+
+	for _, objective := range objectives {
+		if conditional, ok := objective.(conditionObjective); ok && conditional.isPrice {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isAdditive tells whether the objective values a combination by the sum of values of its matches.
+func isAdditive(objective Objective) bool {
+	switch objective.(type) {
+	case MatchObjective, conditionObjective:
+		return true
+	default:
+		return false
+	}
+}
+
+// rank orders matches and combinations of them.
+// Priority is compared first, so a combination with the higher total priority of contract conditions wins regardless of the rest.
+// Then values of configured objectives are compared in their order, and then the score.
+// Movements matched to bundle conditions are the final criterion, so a bundle wins over single activity conditions scoring the same.
+// Beyond rank, combinations dominated by more specific attribute patterns matching their movements lose, see keepDominatingPatterns.
+type rank struct {
+	priority int
+	values   []*big.Rat
+	score    int
+	bundled  int
+}
+
+// rankOf returns the rank of matches.
+// If priority weight is configured, priority is weighted into the first objective, or into score without objectives,
+// instead of being compared first.
+func (m *matcher) rankOf(matches []Match) rank {
+
+	result := rank{}
+
+	for _, match := range matches {
+		result.score += match.Score
+		if match.ContractCondition != nil {
+			result.priority += match.ContractCondition.Priority
+			if isBundle(match.ContractCondition) {
+				result.bundled += len(match.Movements)
+			}
+		}
+	}
+
+	for _, objective := range m.opts.objectives {
+		result.values = append(result.values, objective.Evaluate(matches))
+	}
+
+	if m.opts.priorityWeight != 0 {
+		weighted := m.opts.priorityWeight * result.priority
+		if len(result.values) > 0 {
+			// The value might be held by the objective, so it is not modified in place.
+			result.values[0] = new(big.Rat).Add(result.values[0], new(big.Rat).SetInt64(int64(weighted)))
+		} else {
+			result.score += weighted
+		}
+		result.priority = 0
+	}
+
+	return result
+}
+
+// isBetter tells whether r ranks higher than other.
+func (r rank) isBetter(other rank) bool {
+
+	if r.priority != other.priority {
+		return r.priority > other.priority
+	}
+
+	for valueNo := 0; valueNo < max(len(r.values), len(other.values)); valueNo++ {
+		if cmp := r.value(valueNo).Cmp(other.value(valueNo)); cmp != 0 {
+			return cmp > 0
+		}
+	}
+
+	if r.score != other.score {
+		return r.score > other.score
+	}
+
+	return r.bundled > other.bundled
+}
+
+// value returns the objective value at the position, or zero if there is none, e.g. for the rank of nothing matched.
+func (r rank) value(valueNo int) *big.Rat {
+
+	if valueNo < len(r.values) {
+		return r.values[valueNo]
+	}
+
+	return new(big.Rat)
+}
+
+// add returns the sum of ranks. Objective values are summed up as well, so it is meant for additive objectives only.
+func (r rank) add(other rank) rank {
+
+	result := rank{priority: r.priority + other.priority, score: r.score + other.score, bundled: r.bundled + other.bundled}
+	for valueNo := 0; valueNo < max(len(r.values), len(other.values)); valueNo++ {
+		result.values = append(result.values, new(big.Rat).Add(r.value(valueNo), other.value(valueNo)))
+	}
+
+	return result
+}
+
+// times returns the rank multiplied by n. Objective values are multiplied as well, so it is meant for additive objectives only.
+func (r rank) times(n int) rank {
+
+	result := rank{priority: r.priority * n, score: r.score * n, bundled: r.bundled * n}
+	for _, value := range r.values {
+		result.values = append(result.values, new(big.Rat).Mul(value, new(big.Rat).SetInt64(int64(n))))
+	}
+
+	return result
+}
+
+// max returns the rank which is not below r and other in any of criteria.
+func (r rank) max(other rank) rank {
+
+	result := rank{priority: max(r.priority, other.priority), score: max(r.score, other.score), bundled: max(r.bundled, other.bundled)}
+	for valueNo := 0; valueNo < max(len(r.values), len(other.values)); valueNo++ {
+		value := r.value(valueNo)
+		if other.value(valueNo).Cmp(value) > 0 {
+			value = other.value(valueNo)
+		}
+		result.values = append(result.values, value)
+	}
+
+	return result
+}
+
+// isEqual tells whether r and other rank the same.
+func (r rank) isEqual(other rank) bool {
+	return !r.isBetter(other) && !other.isBetter(r)
+}
@@ -0,0 +1,148 @@
+package application
+
+import (
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// Option configures MatchMovementsToBundleContractConditions.
+type Option func(*options)
+
+// options holds the configuration of a single matching run.
+type options struct {
+	scorer       Scorer
+	tieBreakers  []TieBreaker
+	searchBudget int
+	// priorityWeight weights contract condition priority into score, if not zero
+	priorityWeight int
+	// objectives are compared in their order to select the best combination
+	objectives []Objective
+	// branchLocations maps branch Id to its time zone
+	branchLocations map[string]*time.Location
+	// workers is the number of partitions matched concurrently
+	workers int
+	// logger overrides the logger carried by context, if set
+	logger *slog.Logger
+}
+
+// newOptions returns default options with opts applied.
+func newOptions(opts ...Option) *options {
+
+	o := &options{
+		scorer:  NewWeightedScorer(DefaultScoreWeights()),
+		workers: runtime.GOMAXPROCS(0),
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithScorer makes matching use scorer for evaluating movements against contract condition movement activities.
+// A nil scorer keeps the default one.
+func WithScorer(scorer Scorer) Option {
+	return func(o *options) {
+		if scorer != nil {
+			o.scorer = scorer
+		}
+	}
+}
+
+// WithTieBreakers makes matching resolve ties between best scoring combinations by tieBreakers, applied in the given order.
+// Tie breakers replace previously configured ones.
+// Without tie breakers, i.e. WithTieBreakers(), or if they can not resolve the tie, remaining tied combinations are left for manual review.
+func WithTieBreakers(tieBreakers ...TieBreaker) Option {
+	return func(o *options) {
+		o.tieBreakers = tieBreakers
+	}
+}
+
+// WithSearchBudget limits the search to the given number of steps, where a step is an attempt to assign a movement
+// to a movement activity or to combine a match with matches of leftover movements.
+// Movements of the same contractor, branch and workflow type are matched separately, each within the share of the budget
+// in proportion to their number. Once the share is exceeded, the best combination found so far is returned, or the first one
+// to be found if there is none yet, so the search might take somewhat more steps than the budget. Zero means unlimited.
+// Unlike a deadline of ctx, the budget makes the result of a search too large to be over reproducible.
+func WithSearchBudget(steps int) Option {
+	return func(o *options) {
+		o.searchBudget = steps
+	}
+}
+
+// WithBranchLocations sets time zones of branches, so movement dates are checked against contract condition validity periods
+// in the wall clock time of the movement branch. Dates of movements of other branches are taken as they are.
+func WithBranchLocations(locations map[string]*time.Location) Option {
+	return func(o *options) {
+		o.branchLocations = locations
+	}
+}
+
+// WithPriorityWeight makes matching add contract condition priority multiplied by weight to the score,
+// or to the first of objectives if configured, instead of comparing priority first. Zero weight keeps priority compared first.
+func WithPriorityWeight(weight int) Option {
+	return func(o *options) {
+		o.priorityWeight = weight
+	}
+}
+
+// PriceObjective tells whether matching optimizes total price of combinations instead of their score.
+type PriceObjective int
+
+const (
+	// OptimizeScore selects the best scoring combination.
+	OptimizeScore PriceObjective = iota
+	// MaximizePrice selects the combination with the highest total price, and the best scoring one among equally priced.
+	MaximizePrice
+	// MinimizePrice selects the combination with the lowest total price, and the best scoring one among equally priced.
+	// The lowest price is taken only over combinations matching every movement they can, see ObjectiveCustomerCost.
+	MinimizePrice
+)
+
+// WithPriceObjective makes matching select combinations by total price of their matches instead of score.
+// It is a shorthand for WithObjectives with ObjectiveRevenue or ObjectiveCustomerCost.
+func WithPriceObjective(objective PriceObjective) Option {
+	switch objective {
+	case MaximizePrice:
+		return WithObjectives(ObjectiveRevenue())
+	case MinimizePrice:
+		return WithObjectives(ObjectiveCustomerCost())
+	default:
+		return WithObjectives()
+	}
+}
+
+// WithObjectives makes matching select the combination by objectives compared lexicographically in the given order,
+// e.g. WithObjectives(ObjectiveMatchedMovements(), ObjectiveRevenue()). Priority of contract conditions still goes first,
+// and the score resolves whatever objectives leave equal. Without objectives the best scoring combination is selected.
+// Objectives of the package and MatchObjective ones value matches one by one, so matching skips combinations which could not win,
+// while with ObjectiveFunc ones it goes through every combination. Objectives replace previously configured ones.
+func WithObjectives(objectives ...Objective) Option {
+	return func(o *options) {
+		o.objectives = objectives
+	}
+}
+
+// WithWorkers limits the number of partitions, i.e. movements of the same contractor, branch and workflow type,
+// matched concurrently. By default there are as many workers as GOMAXPROCS. One worker matches partitions one by one.
+// With more than one worker, Scorer, objectives and tie breakers are called concurrently, so they have to be safe for concurrent use.
+// The result does not depend on the number of workers, unless ctx is done before matching is over.
+func WithWorkers(workers int) Option {
+	return func(o *options) {
+		if workers > 0 {
+			o.workers = workers
+		}
+	}
+}
+
+// WithLogger makes matching write logs to logger instead of the one carried by ctx, see ContextWithLogger, or slog.Default().
+// A nil logger keeps the default one.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		if logger != nil {
+			o.logger = logger
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package application
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/ivan-kostko/nrute-matches/domain"
+)
+
+// partitionKey represents movement and contract condition properties which have to be equal for them to match.
+type partitionKey struct {
+	contractor   string
+	branch       string
+	workflowType string
+}
+
+// movementPartitionKey returns the partition key of the movement.
+// Movements without contractor fit contract conditions with empty ContractorIdentifier.
+func movementPartitionKey(mvmt Movement) partitionKey {
+
+	key := partitionKey{branch: mvmt.Branch.Id, workflowType: mvmt.Workflow.Type}
+	if mvmt.User.Contractor != nil {
+		key.contractor = *mvmt.User.Contractor
+	}
+
+	return key
+}
+
+// conditionPartitionKey returns the partition key of the contract condition.
+func conditionPartitionKey(cond *domain.ContractCondition) partitionKey {
+	return partitionKey{contractor: cond.ContractorIdentifier, branch: cond.BranchIdentifier, workflowType: cond.WorkflowType}
+}
+
+// partition represents an independent part of matching: movements along with contract conditions they could match.
+// Movements and conditions keep their input order, so ties are found the same way as without partitioning.
+type partition struct {
+	// mvmtNos holds the input positions of movements.
+	mvmtNos   []int
+	movements []Movement
+	conds     []*domain.ContractCondition
+}
+
+// splitIntoPartitions groups movements and contract conditions by contractor, branch and workflow type,
+// so every group could be matched separately.
+// Groups linked by exclusive contract conditions suppressing conditions of another group are matched together,
+// as matching one of them affects what could be matched in another.
+// Partitions are returned in the order of their first movements, along with input positions of movements which fit no contract condition.
+func splitIntoPartitions(movements []Movement, conds []domain.ContractCondition) ([]partition, []int) {
+
+	condKeys := make([]partitionKey, len(conds))
+	hasConditions := map[partitionKey]bool{}
+	for condNo := range conds {
+		condKeys[condNo] = conditionPartitionKey(&conds[condNo])
+		hasConditions[condKeys[condNo]] = true
+	}
+
+	mvmtKeys := make([]partitionKey, len(movements))
+	hasMovements := map[partitionKey]bool{}
+	for mvmtNo, mvmt := range movements {
+		mvmtKeys[mvmtNo] = movementPartitionKey(mvmt)
+		hasMovements[mvmtKeys[mvmtNo]] = true
+	}
+
+	// Groups are merged by union-find over their keys. Groups without movements have nothing to match, so they are not merged.
+	parents := map[partitionKey]partitionKey{}
+	var root func(key partitionKey) partitionKey
+	root = func(key partitionKey) partitionKey {
+		parent, ok := parents[key]
+		if !ok || parent == key {
+			return key
+		}
+		parents[key] = root(parent)
+		return parents[key]
+	}
+
+	for aNo := range conds {
+		if !conds[aNo].Exclusive || !hasMovements[condKeys[aNo]] {
+			continue
+		}
+		for bNo := range conds {
+			if condKeys[aNo] != condKeys[bNo] && hasMovements[condKeys[bNo]] && suppresses(&conds[aNo], &conds[bNo]) {
+				parents[root(condKeys[bNo])] = root(condKeys[aNo])
+			}
+		}
+	}
+
+	partitions := []partition{}
+	partitionNos := map[partitionKey]int{}
+	unpartitioned := []int{}
+
+	for mvmtNo, mvmt := range movements {
+
+		if !hasConditions[mvmtKeys[mvmtNo]] {
+			unpartitioned = append(unpartitioned, mvmtNo)
+			continue
+		}
+
+		key := root(mvmtKeys[mvmtNo])
+		partitionNo, ok := partitionNos[key]
+		if !ok {
+			partitionNo = len(partitions)
+			partitionNos[key] = partitionNo
+			partitions = append(partitions, partition{})
+		}
+
+		partitions[partitionNo].mvmtNos = append(partitions[partitionNo].mvmtNos, mvmtNo)
+		partitions[partitionNo].movements = append(partitions[partitionNo].movements, mvmt)
+	}
+
+	for condNo := range conds {
+		if !hasMovements[condKeys[condNo]] {
+			continue
+		}
+		partitionNo := partitionNos[root(condKeys[condNo])]
+		partitions[partitionNo].conds = append(partitions[partitionNo].conds, &conds[condNo])
+	}
+
+	return partitions, unpartitioned
+}
+
+// matchPartitions matches partitions concurrently by a pool of workers.
+// Results are placed by partition number, so merging them does not depend on scheduling.
+// Once ctx is done, partitions which have not been picked up by workers yet are left unmatched.
+func (m *matcher) matchPartitions(logger Log, partitions []partition) []MatchResult {
+
+	results := make([]MatchResult, len(partitions))
+	budgets := m.partitionBudgets(partitions)
+
+	partitionNos := make(chan int)
+	wg := sync.WaitGroup{}
+
+	for workerNo := 0; workerNo < min(m.opts.workers, len(partitions)); workerNo++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for partitionNo := range partitionNos {
+				results[partitionNo] = m.matchPartition(logger.WithFields(map[string]interface{}{"partition_no": partitionNo}), partitions[partitionNo], budgets[partitionNo])
+			}
+		}()
+	}
+
+dispatch:
+	for partitionNo := range partitions {
+		select {
+		case partitionNos <- partitionNo:
+		case <-m.ctx.Done():
+			logger.Warn("Context is done. Leaving " + strconv.Itoa(len(partitions)-partitionNo) + " partitions unmatched")
+			for ; partitionNo < len(partitions); partitionNo++ {
+				results[partitionNo] = MatchResult{Unmatched: partitions[partitionNo].movements}
+			}
+			break dispatch
+		}
+	}
+
+	close(partitionNos)
+	wg.Wait()
+
+	return results
+}
+
+// partitionBudgets splits the search budget between partitions in proportion to their numbers of movements,
+// so whether a partition exceeds its share does not depend on the order partitions are matched in.
+// Every partition gets at least one step, while zero budget leaves all of them unlimited.
+func (m *matcher) partitionBudgets(partitions []partition) []int {
+
+	budgets := make([]int, len(partitions))
+	if m.opts.searchBudget <= 0 {
+		return budgets
+	}
+
+	total := 0
+	for _, p := range partitions {
+		total += len(p.movements)
+	}
+
+	for partitionNo, p := range partitions {
+		budgets[partitionNo] = max(1, int(int64(m.opts.searchBudget)*int64(len(p.movements))/int64(total)))
+	}
+
+	return budgets
+}
+
+// mergePartitionResults merges results of partitions into the result of matching all movements.
+// Ties are broken within partitions, so the merged result is tied if any of partitions is,
+// and the tie is resolved only if it is resolved in every tied partition.
+// A partition with unresolved tie leaves its own movements unmatched only, while matches of other partitions are kept.
+// Tied combinations of every partition are listed as they are, so each of them holds matches of the partition movements only.
+func mergePartitionResults(movements []Movement, partitions []partition, results []MatchResult, unpartitioned []int) MatchResult {
+
+	merged := MatchResult{IsTieResolved: true}
+	unmatchedNos := append([]int{}, unpartitioned...)
+
+	for partitionNo, result := range results {
+
+		merged.Bundles = append(merged.Bundles, result.Bundles...)
+		merged.Score += result.Score
+
+		if result.IsTie {
+			merged.IsTie = true
+			merged.IsTieResolved = merged.IsTieResolved && result.IsTieResolved
+			merged.TiedCombinations = append(merged.TiedCombinations, result.TiedCombinations...)
+		}
+
+		// Unmatched movements keep the order of partition movements, so they are picked up by walking both at once.
+		unmatchedNo := 0
+		for mvmtNo, mvmt := range partitions[partitionNo].movements {
+			if unmatchedNo < len(result.Unmatched) && result.Unmatched[unmatchedNo] == mvmt {
+				unmatchedNos = append(unmatchedNos, partitions[partitionNo].mvmtNos[mvmtNo])
+				unmatchedNo++
+			}
+		}
+	}
+
+	merged.IsTieResolved = merged.IsTie && merged.IsTieResolved
+
+	sort.Ints(unmatchedNos)
+	for _, mvmtNo := range unmatchedNos {
+		merged.Unmatched = append(merged.Unmatched, movements[mvmtNo])
+	}
+
+	return merged
+}
+
+// tiedMovementIds returns Ids of movements of partitions which were left unmatched due to unresolved tie.
+func tiedMovementIds(partitions []partition, results []MatchResult) map[string]bool {
+
+	tied := map[string]bool{}
+
+	for partitionNo, result := range results {
+		if !result.IsTie || result.IsTieResolved {
+			continue
+		}
+		for _, mvmt := range partitions[partitionNo].movements {
+			tied[mvmt.Id] = true
+		}
+	}
+
+	return tied
+}
@@ -0,0 +1,212 @@
+package application
+
+import (
+	"path"
+	"strings"
+
+	"github.com/ivan-kostko/nrute-matches/domain"
+)
+
+// patternMatch tells how a movement attribute value matched a contract condition attribute pattern.
+// Greater values are more specific matches.
+type patternMatch int
+
+const (
+	patternMismatch patternMatch = iota
+	patternFallbackMatch
+	patternWildcardMatch
+	patternSetMatch
+	patternDirectMatch
+)
+
+// matchPattern matches the value to the contract condition attribute pattern, with undefined being the fallback value of the attribute.
+// Negated patterns accept anything, so they match as wildcards.
+func matchPattern(pattern, value, undefined string) patternMatch {
+
+	switch {
+	case pattern == value:
+		return patternDirectMatch
+	case pattern == undefined:
+		return patternFallbackMatch
+	case strings.HasPrefix(pattern, domain.Pattern_Negation):
+		if matchSet(strings.TrimPrefix(pattern, domain.Pattern_Negation), value) != patternMismatch {
+			return patternMismatch
+		}
+		return patternWildcardMatch
+	}
+
+	match := matchSet(pattern, value)
+	if match == patternDirectMatch && strings.Contains(pattern, domain.Pattern_SetSeparator) {
+		match = patternSetMatch
+	}
+
+	return match
+}
+
+// matchSet returns the most specific match of the value to any member of the pattern set.
+// Malformed glob members never match.
+func matchSet(pattern, value string) patternMatch {
+
+	result := patternMismatch
+
+	for _, member := range strings.Split(pattern, domain.Pattern_SetSeparator) {
+
+		switch {
+		case member == value:
+			return patternDirectMatch
+		case !isGlob(member):
+		default:
+			if ok, err := path.Match(member, value); ok && err == nil {
+				result = patternWildcardMatch
+			}
+		}
+	}
+
+	return result
+}
+
+// isGlob tells whether the pattern set member contains glob meta characters.
+func isGlob(member string) bool {
+	return strings.ContainsAny(member, `*?[\`)
+}
+
+// isValidPattern tells whether all glob members of the pattern are well formed.
+func isValidPattern(pattern string) bool {
+
+	for _, member := range strings.Split(strings.TrimPrefix(pattern, domain.Pattern_Negation), domain.Pattern_SetSeparator) {
+		if _, err := path.Match(member, ""); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// patternSpecificity ranks the contract condition attribute pattern from 0 for undefined one up to 3 for exact value.
+func patternSpecificity(pattern, undefined string) int {
+
+	match := patternDirectMatch
+	switch {
+	case pattern == undefined:
+		match = patternFallbackMatch
+	case strings.HasPrefix(pattern, domain.Pattern_Negation), isGlob(pattern):
+		match = patternWildcardMatch
+	case strings.Contains(pattern, domain.Pattern_SetSeparator):
+		match = patternSetMatch
+	}
+
+	return int(match - patternFallbackMatch)
+}
+
+// weight picks the weight of the match.
+func (m patternMatch) weight(direct, set, wildcard, fallback int) int {
+
+	switch m {
+	case patternDirectMatch:
+		return direct
+	case patternSetMatch:
+		return set
+	case patternWildcardMatch:
+		return wildcard
+	case patternFallbackMatch:
+		return fallback
+	default:
+		return 0
+	}
+}
+
+// keepDominatingPatterns keeps those of combinations ranking the same which no other one dominates, i.e. matches every movement
+// by contract condition attribute patterns at least as specific, and some movements by more specific ones, e.g. by an option set
+// rather than by a wildcard. Score weights might give such patterns the same points, so dominated combinations rank lower
+// regardless of tie breakers. Combinations being more specific by one attribute and less specific by another are left tied.
+func keepDominatingPatterns(tied [][]Match) [][]Match {
+
+	specificities := make([]map[string][]int, len(tied))
+	totals := make([]int, len(tied))
+	for combinationNo, combination := range tied {
+		specificities[combinationNo] = movementSpecificities(combination)
+		for _, mvmtSpecificities := range specificities[combinationNo] {
+			for _, specificity := range mvmtSpecificities {
+				totals[combinationNo] += specificity
+			}
+		}
+	}
+
+	winners := [][]Match{}
+	for combinationNo, combination := range tied {
+		isDominated := false
+		for otherNo := range tied {
+			// Dominating specificities either sum up higher or cover more movements,
+			// so combinations are not compared movement by movement otherwise.
+			canDominate := totals[otherNo] > totals[combinationNo] || len(specificities[otherNo]) > len(specificities[combinationNo])
+			if otherNo != combinationNo && canDominate && dominates(specificities[otherNo], specificities[combinationNo]) {
+				isDominated = true
+				break
+			}
+		}
+		if !isDominated {
+			winners = append(winners, combination)
+		}
+	}
+
+	return winners
+}
+
+// movementSpecificities returns by movement Id specificities of vehicle type, workflow factor and option patterns
+// the movement is matched by. Unmatched movements are left out.
+// A movement is taken to be matched by the most specific movement activity of its type, which accepts its option.
+func movementSpecificities(combination []Match) map[string][]int {
+
+	result := map[string][]int{}
+
+	for _, match := range combination {
+
+		cond := match.ContractCondition
+		if cond == nil {
+			continue
+		}
+
+		for _, mvmt := range match.Movements {
+
+			option := -1
+			for _, ccma := range cond.MovementActivities {
+				if ccma.Type == mvmt.Type && matchPattern(ccma.Option, mvmt.Option, domain.Undefined_MovementOption) != patternMismatch {
+					option = max(option, patternSpecificity(ccma.Option, domain.Undefined_MovementOption))
+				}
+			}
+
+			result[mvmt.Id] = []int{
+				patternSpecificity(cond.VehicleType, domain.Undefined_VehicleType),
+				patternSpecificity(cond.WorkflowFactor, domain.Undefined_WorkflowFactor),
+				option,
+			}
+		}
+	}
+
+	return result
+}
+
+// dominates tells whether specificities a are at least as high as b for every movement and attribute, and higher for some.
+// Movements missing in specificities are unmatched, which is less specific than any pattern.
+func dominates(a, b map[string][]int) bool {
+
+	isHigher := false
+
+	for mvmtId, bSpecificities := range b {
+		aSpecificities, ok := a[mvmtId]
+		if !ok {
+			return false
+		}
+		for attributeNo := range bSpecificities {
+			switch {
+			case aSpecificities[attributeNo] < bSpecificities[attributeNo]:
+				return false
+			case aSpecificities[attributeNo] > bSpecificities[attributeNo]:
+				isHigher = true
+			}
+		}
+	}
+
+	// Movements matched by a only are more specific than unmatched ones.
+	return isHigher || len(a) > len(b)
+}
@@ -0,0 +1,147 @@
+package application
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+
+	"github.com/ivan-kostko/nrute-matches/domain"
+)
+
+// InvoiceLine represents the price of a match to a contract condition.
+type InvoiceLine struct {
+	ContractCondition *domain.ContractCondition
+	Movements         []Movement
+	Currency          string
+	// Flat, Activities and Surcharges break Amount down by components of the price model.
+	Flat       *big.Rat
+	Activities *big.Rat
+	Surcharges *big.Rat
+	Amount     *big.Rat
+}
+
+// Invoice represents priced matches.
+type Invoice struct {
+	Lines []InvoiceLine
+	// Totals holds the total amount of lines by currency.
+	Totals map[string]*big.Rat
+}
+
+// PriceMatches turns matches into invoice lines, e.g. the result of MatchMovementsToBundleContractConditions.
+// Matches without contract condition, i.e. unmatched movements, and matches to conditions with zero price model are not priced.
+// Amounts are exact, so it is up to the caller how to round them, e.g. Amount.FloatString(2).
+// Returns ErrInvalidPrice if some contract condition price model has malformed amount.
+func PriceMatches(matches []Match) (Invoice, error) {
+
+	invoice := Invoice{Lines: []InvoiceLine{}, Totals: map[string]*big.Rat{}}
+
+	for _, match := range matches {
+
+		if match.ContractCondition == nil || !isPriced(match.ContractCondition.Price) {
+			continue
+		}
+
+		line, err := priceMatch(match)
+		if err != nil {
+			return Invoice{}, err
+		}
+
+		invoice.Lines = append(invoice.Lines, line)
+
+		if _, ok := invoice.Totals[line.Currency]; !ok {
+			invoice.Totals[line.Currency] = new(big.Rat)
+		}
+		invoice.Totals[line.Currency].Add(invoice.Totals[line.Currency], line.Amount)
+	}
+
+	return invoice, nil
+}
+
+// isPriced tells whether the price model is set at all, as its zero value leaves matches of the condition unpriced.
+func isPriced(model domain.PriceModel) bool {
+	return model.Currency != "" || model.Flat != "" || len(model.PerActivity) > 0 || len(model.WorkflowFactorSurcharges) > 0
+}
+
+// priceMatch prices the match by its contract condition price model.
+func priceMatch(match Match) (InvoiceLine, error) {
+
+	cond := match.ContractCondition
+	model := cond.Price
+
+	line := InvoiceLine{
+		ContractCondition: cond,
+		Movements:         match.Movements,
+		Currency:          model.Currency,
+		Activities:        new(big.Rat),
+		Surcharges:        new(big.Rat),
+		Amount:            new(big.Rat),
+	}
+
+	var err error
+	if line.Flat, err = parseAmount(cond, model.Flat); err != nil {
+		return InvoiceLine{}, err
+	}
+
+	for _, mvmt := range match.Movements {
+
+		activityPrice, err := parseAmount(cond, model.PerActivity[mvmt.Type])
+		if err != nil {
+			return InvoiceLine{}, err
+		}
+		line.Activities.Add(line.Activities, activityPrice)
+
+		surcharge, err := parseAmount(cond, model.WorkflowFactorSurcharges[mvmt.Workflow.Factor])
+		if err != nil {
+			return InvoiceLine{}, err
+		}
+		line.Surcharges.Add(line.Surcharges, surcharge)
+	}
+
+	line.Amount.Add(line.Flat, line.Activities)
+	line.Amount.Add(line.Amount, line.Surcharges)
+
+	return line, nil
+}
+
+// amountPattern matches plain non-negative decimals, e.g. "12" or "12.50".
+// Rat accepts fractions, exponents, hexadecimals and signs as well, but price models are expected to hold none of them.
+var amountPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// parseAmount parses the decimal amount of the contract condition price model. Empty amount is zero.
+func parseAmount(cond *domain.ContractCondition, amount string) (*big.Rat, error) {
+
+	if amount == "" {
+		return new(big.Rat), nil
+	}
+
+	if !amountPattern.MatchString(amount) {
+		return nil, fmt.Errorf("%w: contract condition %q has malformed amount %q", ErrInvalidPrice, cond.Id, amount)
+	}
+
+	parsed, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return nil, fmt.Errorf("%w: contract condition %q has malformed amount %q", ErrInvalidPrice, cond.Id, amount)
+	}
+
+	return parsed, nil
+}
+
+// checkPriceModel returns ErrInvalidPrice if any amount of the contract condition price model is malformed.
+func checkPriceModel(cond *domain.ContractCondition) error {
+
+	amounts := []string{cond.Price.Flat}
+	for _, amount := range cond.Price.PerActivity {
+		amounts = append(amounts, amount)
+	}
+	for _, amount := range cond.Price.WorkflowFactorSurcharges {
+		amounts = append(amounts, amount)
+	}
+
+	for _, amount := range amounts {
+		if _, err := parseAmount(cond, amount); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
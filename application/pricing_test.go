@@ -0,0 +1,137 @@
+package application_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ivan-kostko/nrute-matches/application"
+	"github.com/ivan-kostko/nrute-matches/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriceMatches(t *testing.T) {
+
+	turnaround := &domain.ContractCondition{
+		Id: "Turnaround",
+		Price: domain.PriceModel{
+			Currency:                 "EUR",
+			Flat:                     "10.00",
+			PerActivity:              map[string]string{"checkin": "1.10", "parking": "2.20"},
+			WorkflowFactorSurcharges: map[string]string{"express": "0.35"},
+		},
+	}
+	parking := &domain.ContractCondition{
+		Id:    "Parking",
+		Price: domain.PriceModel{Currency: "USD", PerActivity: map[string]string{"parking": "0.10"}},
+	}
+	unpriced := &domain.ContractCondition{Id: "Unpriced"}
+	broken := &domain.ContractCondition{
+		Id:    "Broken",
+		Price: domain.PriceModel{Currency: "EUR", Flat: "ten"},
+	}
+
+	testCases := []struct {
+		Alias           string
+		MatchesIn       []application.Match
+		ExpectedAmounts []string
+		ExpectedTotals  map[string]string
+		ExpectedErr     error
+	}{
+		{
+			Alias: `Lines are priced by components of price model`,
+			MatchesIn: []application.Match{
+				application.Match{
+					Movements: []application.Movement{
+						{Id: "1", Type: "checkin", Workflow: application.Workflow{Factor: "express"}},
+						{Id: "2", Type: "parking", Workflow: application.Workflow{Factor: "standard"}},
+					},
+					ContractCondition: turnaround,
+				},
+				application.Match{
+					Movements:         []application.Movement{{Id: "3", Type: "parking"}},
+					ContractCondition: parking,
+				},
+				application.Match{
+					Movements: []application.Movement{{Id: "4", Type: "wash"}},
+				},
+			},
+			ExpectedAmounts: []string{"13.65", "0.10"},
+			ExpectedTotals:  map[string]string{"EUR": "13.65", "USD": "0.10"},
+		},
+		{
+			Alias: `Matches to conditions with zero price model are not priced`,
+			MatchesIn: []application.Match{
+				application.Match{
+					Movements:         []application.Movement{{Id: "1", Type: "parking"}},
+					ContractCondition: parking,
+				},
+				application.Match{
+					Movements:         []application.Movement{{Id: "2", Type: "checkin"}},
+					ContractCondition: unpriced,
+				},
+			},
+			ExpectedAmounts: []string{"0.10"},
+			ExpectedTotals:  map[string]string{"USD": "0.10"},
+		},
+		{
+			Alias: `Malformed amount`,
+			MatchesIn: []application.Match{
+				application.Match{
+					Movements:         []application.Movement{{Id: "1", Type: "checkin"}},
+					ContractCondition: broken,
+				},
+			},
+			ExpectedErr: application.ErrInvalidPrice,
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			actualInvoice, err := application.PriceMatches(tCase.MatchesIn)
+
+			if tCase.ExpectedErr != nil {
+				assert.ErrorIs(t, err, tCase.ExpectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+
+			actualAmounts := []string{}
+			for _, line := range actualInvoice.Lines {
+				actualAmounts = append(actualAmounts, line.Amount.FloatString(2))
+			}
+			assert.Equal(t, tCase.ExpectedAmounts, actualAmounts)
+
+			actualTotals := map[string]string{}
+			for currency, total := range actualInvoice.Totals {
+				actualTotals[currency] = total.FloatString(2)
+			}
+			assert.Equal(t, tCase.ExpectedTotals, actualTotals)
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func TestPriceMatches_IsExact(t *testing.T) {
+
+	cond := &domain.ContractCondition{
+		Id:    "Parking",
+		Price: domain.PriceModel{Currency: "EUR", PerActivity: map[string]string{"parking": "0.1"}},
+	}
+
+	matches := []application.Match{}
+	for mvmtNo := 0; mvmtNo < 3; mvmtNo++ {
+		matches = append(matches, application.Match{Movements: []application.Movement{{Type: "parking"}}, ContractCondition: cond})
+	}
+
+	actualInvoice, err := application.PriceMatches(matches)
+
+	assert.NoError(t, err)
+
+	expectedTotal, _ := new(big.Rat).SetString("0.3")
+	assert.Equal(t, 0, expectedTotal.Cmp(actualInvoice.Totals["EUR"]))
+}
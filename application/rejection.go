@@ -0,0 +1,231 @@
+package application
+
+import (
+	"log/slog"
+
+	"github.com/ivan-kostko/nrute-matches/domain"
+)
+
+// RejectionReason tells why a movement was not matched to a contract condition.
+type RejectionReason string
+
+const (
+	RejectionReasonNone                         RejectionReason = ""
+	RejectionReasonNoMovementActivities         RejectionReason = "no_movement_activities"
+	RejectionReasonContractorMismatch           RejectionReason = "contractor_mismatch"
+	RejectionReasonBranchMismatch               RejectionReason = "branch_mismatch"
+	RejectionReasonWorkflowTypeMismatch         RejectionReason = "workflow_type_mismatch"
+	RejectionReasonMovementActivityTypeMismatch RejectionReason = "movement_activity_type_mismatch"
+	RejectionReasonOutOfValidity                RejectionReason = "out_of_validity_period"
+	RejectionReasonVehicleTypeMismatch          RejectionReason = "vehicle_type_mismatch"
+	RejectionReasonWorkflowFactorMismatch       RejectionReason = "workflow_factor_mismatch"
+	RejectionReasonOptionMismatch               RejectionReason = "option_mismatch"
+	// RejectionReasonScorer is given when a custom Scorer rejected the movement without explaining why.
+	RejectionReasonScorer RejectionReason = "rejected_by_scorer"
+	// RejectionReasonTimeWindow is given when the movement fits the bundle contract condition,
+	// but is too far from or too close to the other fitting movements.
+	RejectionReasonTimeWindow RejectionReason = "time_window"
+	// RejectionReasonIncoherent is given when the movement fits the bundle contract condition,
+	// but differs from the other fitting movements by an attribute which must be equal across the bundle.
+	RejectionReasonIncoherent RejectionReason = "incoherent_bundle"
+	// RejectionReasonOutOfSequence is given when the movement fits the ordered bundle contract condition,
+	// but happens out of the order of movement activities relative to the other fitting movements.
+	RejectionReasonOutOfSequence RejectionReason = "out_of_sequence"
+	// RejectionReasonSuppressed is given when the movement fits the contract condition,
+	// but the condition is suppressed by an exclusive one matched to other movements, or suppresses one of them itself.
+	RejectionReasonSuppressed RejectionReason = "suppressed"
+	// RejectionReasonMaxInstances is given when the movement fits the contract condition,
+	// but the condition is already matched to other movements as many times as its MaxInstances allows.
+	RejectionReasonMaxInstances RejectionReason = "max_instances"
+	// RejectionReasonInsufficientMovements is given when the movement fits the contract condition,
+	// but there were not enough other fitting movements left to fulfil all its movement activities.
+	// It is given only once the search is over, as an interrupted search might have left the movement unmatched for no reason.
+	RejectionReasonInsufficientMovements RejectionReason = "insufficient_movements"
+	// RejectionReasonUnexplained is given when the movement fits the bundle contract condition,
+	// but there were too many ways to assign fitting movements to its movement activities to check all bundle constraints.
+	// Once matching is interrupted, it is given for every movement and contract condition pair left to explain.
+	RejectionReasonUnexplained RejectionReason = "unexplained"
+	// RejectionReasonTieUnresolved is given when the movement fits the contract condition,
+	// but it was left unmatched due to the unresolved tie between best scoring combinations.
+	RejectionReasonTieUnresolved RejectionReason = "tie_unresolved"
+)
+
+// explanationBudget limits the number of steps taken to explain bundle constraint rejections of a single contract condition.
+// Explanation is interrupted by ctx and the search budget as well, so bundle constraints of an interrupted search are left unexplained.
+const explanationBudget = 1 << 20
+
+// Rejection represents the reason why the movement was not matched to the contract condition.
+type Rejection struct {
+	Movement          Movement
+	ContractCondition *domain.ContractCondition
+	Reason            RejectionReason
+}
+
+// RejectionExplainer is implemented by Scorers which are able to tell why they rejected a movement.
+type RejectionExplainer interface {
+	ExplainRejection(cond *domain.ContractCondition, ccma domain.MovementActivity, mvmt Movement) RejectionReason
+}
+
+// explainRejections returns rejection reasons for every unmatched movement and contract condition pair.
+// Once ctx is done or the search budget is exceeded, remaining pairs are told to be RejectionReasonUnexplained.
+// Bundle constraints are explained against all movements of the condition partition, as unmatched movements might not fit
+// only the ones taken by other bundles. Movements by Ids in tied were left unmatched due to unresolved tie.
+func (m *matcher) explainRejections(logger Log, movements []Movement, unmatched []Movement, bundles []Match, conds []domain.ContractCondition, tied map[string]bool) []Rejection {
+
+	rejections := make([]Rejection, 0, len(unmatched)*len(conds))
+	isInterrupted := false
+
+	isDebug := isDebugEnabled(logger)
+
+	// Only movements of the same contractor, branch and workflow type could fit the condition, so the rest are not assigned.
+	partitionMovements := map[partitionKey][]Movement{}
+	for _, mvmt := range movements {
+		key := movementPartitionKey(mvmt)
+		partitionMovements[key] = append(partitionMovements[key], mvmt)
+	}
+
+	for condNo := range conds {
+
+		cond := &conds[condNo]
+		condLogger := logger.WithFields(map[string]interface{}{"contract_condition_id": cond.Id, "contract_condition_name": cond.Name})
+
+		// Bundle constraints are explained only for movements fitting the condition, so they are evaluated lazily.
+		var bundleReasons map[string]RejectionReason
+
+		for _, mvmt := range unmatched {
+
+			// Explanation takes long for many movements and conditions, so it is left off for the rest of pairs once matching is interrupted.
+			isInterrupted = isInterrupted || m.interruption() != nil
+			if isInterrupted {
+				rejections = append(rejections, Rejection{Movement: mvmt, ContractCondition: cond, Reason: RejectionReasonUnexplained})
+				continue
+			}
+
+			reason := m.explainRejection(condLogger, cond, mvmt)
+
+			if reason == RejectionReasonNone && isBundle(cond) {
+				if bundleReasons == nil {
+					bundleReasons = m.explainBundleRejections(condLogger, partitionMovements[conditionPartitionKey(cond)], cond)
+				}
+				reason = bundleReasons[mvmt.Id]
+			}
+
+			if reason == RejectionReasonNone && isSuppressedBy(cond, bundles) {
+				reason = RejectionReasonSuppressed
+			}
+
+			if reason == RejectionReasonNone && hasMaxInstances(cond, bundles) {
+				reason = RejectionReasonMaxInstances
+			}
+
+			if reason == RejectionReasonNone {
+				reason = RejectionReasonInsufficientMovements
+				if tied[mvmt.Id] {
+					reason = RejectionReasonTieUnresolved
+				}
+			}
+
+			if isDebug {
+				condLogger.Debug("Movement is rejected with reason "+string(reason), slog.String("movement_id", mvmt.Id))
+			}
+
+			rejections = append(rejections, Rejection{Movement: mvmt, ContractCondition: cond, Reason: reason})
+		}
+	}
+
+	return rejections
+}
+
+// hasMaxInstances tells whether the contract condition is capped by MaxInstances and matched by matches as many times as it allows.
+// Instances are counted per condition rather than per Id, as versions of the same condition are capped separately.
+func hasMaxInstances(cond *domain.ContractCondition, matches []Match) bool {
+
+	if cond.MaxInstances == 0 {
+		return false
+	}
+
+	instances := 0
+	for _, match := range matches {
+		if match.ContractCondition == cond {
+			instances++
+		}
+	}
+
+	return instances >= cond.MaxInstances
+}
+
+// explainRejection tells why the movement does not fit the contract condition.
+// Returns RejectionReasonNone if the movement fits at least one of contract condition movement activities.
+// Otherwise the reason given by the movement activity of the same type is preferred, as it is the most specific one.
+func (m *matcher) explainRejection(logger Log, cond *domain.ContractCondition, mvmt Movement) RejectionReason {
+
+	if len(cond.MovementActivities) == 0 {
+		logger.Debug("ContractCondition has no movement activities, so it does not match anything.")
+		return RejectionReasonNoMovementActivities
+	}
+
+	reason := RejectionReasonNone
+
+	for _, ccma := range cond.MovementActivities {
+
+		_, maReason := m.matchMovementToActivity(logger, cond, ccma, mvmt)
+
+		switch {
+		case maReason == RejectionReasonNone:
+			return RejectionReasonNone
+		case reason == RejectionReasonNone, reason == RejectionReasonMovementActivityTypeMismatch:
+			reason = maReason
+		}
+	}
+
+	return reason
+}
+
+// explainBundleRejections tells by movement Id for movements which fit the bundle contract condition,
+// which bundle constraint prevented them from being matched together with other movements.
+// Movements being part of at least one assignment satisfying all constraints, or not being part of any assignment at all,
+// are left out. If assignments exceed explanationBudget, or ctx is done, or the search budget is exceeded,
+// all movements are told to be RejectionReasonUnexplained.
+func (m *matcher) explainBundleRejections(logger Log, movements []Movement, cond *domain.ContractCondition) map[string]RejectionReason {
+
+	reasons := map[string]RejectionReason{}
+	satisfied := map[string]bool{}
+
+	steps := 0
+	interrupted := func() bool {
+		steps++
+		return steps > explanationBudget || m.interruption() != nil
+	}
+
+	isExplained := !interrupted() && m.forEachAssignment(logger, movements, cond, interrupted, func(assignment []int, _ int) {
+
+		mvmts := make([]Movement, 0, len(assignment))
+		for _, mvmtNo := range assignment {
+			mvmts = append(mvmts, movements[mvmtNo])
+		}
+
+		reason := m.checkBundle(cond, mvmts)
+
+		for _, mvmt := range mvmts {
+			switch {
+			case reason == RejectionReasonNone:
+				satisfied[mvmt.Id] = true
+			case reasons[mvmt.Id] == RejectionReasonNone:
+				reasons[mvmt.Id] = reason
+			}
+		}
+	})
+
+	if !isExplained {
+		logger.Debug("Too many assignments to explain bundle constraint rejections, or the search is interrupted")
+		for _, mvmt := range movements {
+			reasons[mvmt.Id] = RejectionReasonUnexplained
+		}
+	}
+
+	for mvmtId := range satisfied {
+		delete(reasons, mvmtId)
+	}
+
+	return reasons
+}
@@ -0,0 +1,35 @@
+package application
+
+// MatchResult represents the outcome of matching movements to contract conditions.
+type MatchResult struct {
+	// Bundles is the selected combination of matches to contract conditions.
+	Bundles []Match
+	// Unmatched holds movements which are not part of any of Bundles.
+	Unmatched []Movement
+	// Rejections explains for every unmatched movement and contract condition pair why the movement was not matched to the condition.
+	Rejections []Rejection
+	// Score is the total score of Bundles.
+	Score int
+	// IsTie tells whether more than one combination had the best score.
+	IsTie bool
+	// IsTieResolved tells whether tie breakers managed to select one of tied combinations.
+	// Unresolved tie leaves movements of the same contractor, branch and workflow type as the tied ones unmatched.
+	IsTieResolved bool
+	// TiedCombinations holds all combinations which had the best score, so they could be reviewed manually.
+	TiedCombinations [][]Match
+}
+
+// Matches returns Bundles along with unmatched movements represented by a Match without ContractCondition.
+func (r MatchResult) Matches() []Match {
+
+	if len(r.Bundles) == 0 {
+		return []Match{Match{Movements: r.Unmatched}}
+	}
+
+	matches := append([]Match{}, r.Bundles...)
+	if len(r.Unmatched) > 0 {
+		matches = append(matches, Match{Movements: r.Unmatched})
+	}
+
+	return matches
+}
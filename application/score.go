@@ -0,0 +1,209 @@
+package application
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/ivan-kostko/nrute-matches/domain"
+)
+
+// ActivityScore represents the score of a single movement evaluated against a contract condition movement activity.
+// It is evaluated standalone and gets committed to a Match only when the movement is accepted for the activity,
+// so a movement rejected by a later check never leaves partial points behind.
+type ActivityScore struct {
+	VehicleType    int
+	WorkflowFactor int
+	Option         int
+	// Custom holds the score contributed by attributes other than the built-in ones.
+	Custom int
+}
+
+// Total returns the sum of all score components.
+func (s ActivityScore) Total() int {
+	return s.VehicleType + s.WorkflowFactor + s.Option + s.Custom
+}
+
+// Scorer evaluates a movement against a contract condition movement activity.
+// The movement has already been checked to fit the contract condition by contractor, branch, workflow type and movement type.
+// Returns the score of the pair and true if the movement is acceptable for the activity.
+type Scorer interface {
+	Score(cond *domain.ContractCondition, ccma domain.MovementActivity, mvmt Movement) (ActivityScore, bool)
+}
+
+// ScoreWeights represents the points given for direct, set member, wildcard and fallback matches of movement attributes.
+type ScoreWeights struct {
+	VehicleTypeDirectMatch   int `json:"vehicle_type_direct_match"`
+	VehicleTypeSetMatch      int `json:"vehicle_type_set_match"`
+	VehicleTypeWildcardMatch int `json:"vehicle_type_wildcard_match"`
+	VehicleTypeFallbackMatch int `json:"vehicle_type_fallback_match"`
+	// VehicleTypeAncestorPenalty is subtracted for every taxonomy level between the movement vehicle type and the matched one.
+	VehicleTypeAncestorPenalty          int `json:"vehicle_type_ancestor_penalty"`
+	WorkflowFactorDirectMatch           int `json:"workflow_factor_direct_match"`
+	WorkflowFactorSetMatch              int `json:"workflow_factor_set_match"`
+	WorkflowFactorWildcardMatch         int `json:"workflow_factor_wildcard_match"`
+	WorkflowFactorFallbackMatch         int `json:"workflow_factor_fallback_match"`
+	MovementActivityOptionDirectMatch   int `json:"movement_activity_option_direct_match"`
+	MovementActivityOptionSetMatch      int `json:"movement_activity_option_set_match"`
+	MovementActivityOptionWildcardMatch int `json:"movement_activity_option_wildcard_match"`
+	MovementActivityOptionFallbackMatch int `json:"movement_activity_option_fallback_match"`
+}
+
+// DefaultScoreWeights returns the weights used unless configured otherwise.
+// Set member and wildcard matches of vehicle types score in between direct and fallback ones. Workflow factor wildcard and
+// fallback matches score the same, as do option set member, wildcard and fallback ones, so scoring alone does not rank them.
+// Combinations scoring the same are ranked by specificity of patterns matching their movements then, before any tie breaker.
+func DefaultScoreWeights() ScoreWeights {
+	return ScoreWeights{
+		VehicleTypeDirectMatch:              3,
+		VehicleTypeSetMatch:                 2,
+		VehicleTypeWildcardMatch:            1,
+		VehicleTypeFallbackMatch:            0,
+		VehicleTypeAncestorPenalty:          1,
+		WorkflowFactorDirectMatch:           2,
+		WorkflowFactorSetMatch:              1,
+		WorkflowFactorWildcardMatch:         0,
+		WorkflowFactorFallbackMatch:         0,
+		MovementActivityOptionDirectMatch:   1,
+		MovementActivityOptionSetMatch:      0,
+		MovementActivityOptionWildcardMatch: 0,
+		MovementActivityOptionFallbackMatch: 0,
+	}
+}
+
+// ParseScoreWeights parses JSON encoded weights, e.g. loaded from config.
+// Weights missing in data keep their default values.
+func ParseScoreWeights(data []byte) (ScoreWeights, error) {
+
+	weights := DefaultScoreWeights()
+
+	if err := json.Unmarshal(data, &weights); err != nil {
+		return ScoreWeights{}, err
+	}
+
+	return weights, nil
+}
+
+// WeightedScorer is the default Scorer.
+// It accepts movements whose vehicle type, workflow factor and option match the contract condition patterns or hit the fallback,
+// and scores them with its weights by the kind of match.
+// Vehicle types are also matched to their ancestors in Taxonomy, if it is set.
+type WeightedScorer struct {
+	Weights  ScoreWeights
+	Taxonomy VehicleTaxonomy
+}
+
+// NewWeightedScorer returns WeightedScorer with weights.
+func NewWeightedScorer(weights ScoreWeights) *WeightedScorer {
+	return &WeightedScorer{Weights: weights}
+}
+
+// Score implements Scorer.
+func (s *WeightedScorer) Score(cond *domain.ContractCondition, ccma domain.MovementActivity, mvmt Movement) (ActivityScore, bool) {
+	score, reason := s.evaluate(cond, ccma, mvmt)
+	return score, reason == RejectionReasonNone
+}
+
+// ExplainRejection implements RejectionExplainer.
+func (s *WeightedScorer) ExplainRejection(cond *domain.ContractCondition, ccma domain.MovementActivity, mvmt Movement) RejectionReason {
+	_, reason := s.evaluate(cond, ccma, mvmt)
+	return reason
+}
+
+// evaluate returns the score of the movement and RejectionReasonNone, or zero score and the reason of rejection.
+func (s *WeightedScorer) evaluate(cond *domain.ContractCondition, ccma domain.MovementActivity, mvmt Movement) (ActivityScore, RejectionReason) {
+
+	score := ActivityScore{}
+
+	// Check for VehicleType match
+	vehicleTypeScore, ok := s.vehicleTypeScore(cond.VehicleType, mvmt.Vehicle.Type)
+	if !ok {
+		return ActivityScore{}, RejectionReasonVehicleTypeMismatch
+	}
+	score.VehicleType = vehicleTypeScore
+
+	// Check for WorkflowFactor match
+	workflowFactorMatch := matchPattern(cond.WorkflowFactor, mvmt.Workflow.Factor, domain.Undefined_WorkflowFactor)
+	if workflowFactorMatch == patternMismatch {
+		return ActivityScore{}, RejectionReasonWorkflowFactorMismatch
+	}
+	score.WorkflowFactor = workflowFactorMatch.weight(s.Weights.WorkflowFactorDirectMatch, s.Weights.WorkflowFactorSetMatch, s.Weights.WorkflowFactorWildcardMatch, s.Weights.WorkflowFactorFallbackMatch)
+
+	// Check for Option match
+	optionMatch := matchPattern(ccma.Option, mvmt.Option, domain.Undefined_MovementOption)
+	if optionMatch == patternMismatch {
+		return ActivityScore{}, RejectionReasonOptionMismatch
+	}
+	score.Option = optionMatch.weight(s.Weights.MovementActivityOptionDirectMatch, s.Weights.MovementActivityOptionSetMatch, s.Weights.MovementActivityOptionWildcardMatch, s.Weights.MovementActivityOptionFallbackMatch)
+
+	return score, RejectionReasonNone
+}
+
+// vehicleTypeScore scores the movement vehicle type against the contract condition pattern.
+// If the pattern does not match the vehicle type itself, it is matched to the closest ancestor in Taxonomy,
+// losing VehicleTypeAncestorPenalty per level, but never scoring below the fallback match.
+// Negated patterns are not matched to ancestors, as any ancestor would pass them.
+func (s *WeightedScorer) vehicleTypeScore(pattern, vehicleType string) (int, bool) {
+
+	match := matchPattern(pattern, vehicleType, domain.Undefined_VehicleType)
+	distance := 0
+
+	if match == patternMismatch && !strings.HasPrefix(pattern, domain.Pattern_Negation) {
+		for ancestorNo, ancestor := range s.Taxonomy.ancestors(vehicleType) {
+			if match = matchPattern(pattern, ancestor, domain.Undefined_VehicleType); match != patternMismatch {
+				distance = ancestorNo + 1
+				break
+			}
+		}
+	}
+
+	if match == patternMismatch {
+		return 0, false
+	}
+
+	score := match.weight(s.Weights.VehicleTypeDirectMatch, s.Weights.VehicleTypeSetMatch, s.Weights.VehicleTypeWildcardMatch, s.Weights.VehicleTypeFallbackMatch)
+
+	if distance > 0 {
+		score -= distance * s.Weights.VehicleTypeAncestorPenalty
+		if score < s.Weights.VehicleTypeFallbackMatch {
+			score = s.Weights.VehicleTypeFallbackMatch
+		}
+	}
+
+	return score, true
+}
+
+// ContractorScorer delegates scoring to the Scorer registered for the contract condition contractor.
+// Contractors without own Scorer are scored by Default, or by WeightedScorer with default weights if Default is nil.
+type ContractorScorer struct {
+	Scorers map[string]Scorer
+	Default Scorer
+}
+
+// Score implements Scorer.
+func (s *ContractorScorer) Score(cond *domain.ContractCondition, ccma domain.MovementActivity, mvmt Movement) (ActivityScore, bool) {
+	return s.scorerFor(cond).Score(cond, ccma, mvmt)
+}
+
+// ExplainRejection implements RejectionExplainer.
+func (s *ContractorScorer) ExplainRejection(cond *domain.ContractCondition, ccma domain.MovementActivity, mvmt Movement) RejectionReason {
+
+	if explainer, ok := s.scorerFor(cond).(RejectionExplainer); ok {
+		return explainer.ExplainRejection(cond, ccma, mvmt)
+	}
+
+	return RejectionReasonScorer
+}
+
+// scorerFor returns the Scorer of the contract condition contractor.
+func (s *ContractorScorer) scorerFor(cond *domain.ContractCondition) Scorer {
+
+	if scorer, ok := s.Scorers[cond.ContractorIdentifier]; ok && scorer != nil {
+		return scorer
+	}
+
+	if s.Default != nil {
+		return s.Default
+	}
+
+	return NewWeightedScorer(DefaultScoreWeights())
+}
@@ -0,0 +1,61 @@
+package application_test
+
+import (
+	"testing"
+
+	"github.com/ivan-kostko/nrute-matches/application"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseScoreWeights(t *testing.T) {
+
+	testCases := []struct {
+		Alias           string
+		DataIn          string
+		ExpectedWeights application.ScoreWeights
+		ExpectedErr     bool
+	}{
+		{
+			Alias:           `Empty config keeps defaults`,
+			DataIn:          `{}`,
+			ExpectedWeights: application.DefaultScoreWeights(),
+		},
+		{
+			Alias:  `Configured weights override defaults`,
+			DataIn: `{"vehicle_type_direct_match": 1, "workflow_factor_direct_match": 4}`,
+			ExpectedWeights: application.ScoreWeights{
+				VehicleTypeDirectMatch:            1,
+				VehicleTypeSetMatch:               2,
+				VehicleTypeWildcardMatch:          1,
+				VehicleTypeAncestorPenalty:        1,
+				WorkflowFactorDirectMatch:         4,
+				WorkflowFactorSetMatch:            1,
+				MovementActivityOptionDirectMatch: 1,
+			},
+		},
+		{
+			Alias:       `Broken config`,
+			DataIn:      `{"vehicle_type_direct_match": "many"}`,
+			ExpectedErr: true,
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			actualWeights, err := application.ParseScoreWeights([]byte(tCase.DataIn))
+
+			if tCase.ExpectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tCase.ExpectedWeights, actualWeights)
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
@@ -0,0 +1,1150 @@
+package application
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ivan-kostko/nrute-matches/domain"
+)
+
+// search finds the best combinations of matches to contract conditions.
+//
+// Movements which none of contract conditions tells apart are interchangeable, so they are grouped into classes,
+// and the search deals with numbers of movements left in classes instead of movements themselves. Classes which could be swapped
+// for each other in every match are merged as well. Ways to match every contract condition are found upfront,
+// once per multiset of classes, along with the best score of assigning movements of those classes to the condition.
+//
+// The search goes through classes in turn. Each step takes the first class with movements left, and either matches
+// its movement along with movements of further classes to one of contract conditions, or leaves it unmatched.
+// A movement is left unmatched only if it could not be matched along with other unmatched movements in the end,
+// as any match is better than unmatched movements. Classes linked by contract conditions go one after another,
+// so steps of independent groups of them, e.g. movements of different vehicles, do not multiply.
+//
+// A state of the search is the numbers of movements left in classes along with contract conditions still allowed,
+// numbers of instances of conditions capped by MaxInstances and movements left unmatched so far which could still be matched.
+// Every state is solved once and its best completions are memoized, as the same state is reached by matching classes in a different order.
+// Ranking is additive over matches, so alternatives which could not reach the best completion of the state by upper bound are pruned.
+// Alternatives which could reach it are exercised, so ties are found regardless of the order of input. Combinations matching the same
+// contract conditions do not make a tie, so groups whose matches are all to the same condition and rank the same are the exception:
+// alternatives which could only tie the best completion of such a group are pruned as well, e.g. pairing checkins and parkings
+// within the span of the condition in another way.
+// Objectives valuing matches one by one keep ranking additive, while with other ones every combination is ranked as a whole instead.
+//
+// The search takes as many steps as it has to, unless its budget is limited. Once it is interrupted, alternatives are no longer tried,
+// and completions found so far are returned, or the first one to be found if there are none yet.
+// The search is exact, so the number of steps grows exponentially with movements which contract conditions combine in many ways.
+// Partitions and spans of conditions keep the groups searched through small: a day of a thousand vehicles of fifty types with
+// their checkins, washes and parkings against two hundred conditions is matched in about half a second, see
+// BenchmarkMatchMovements_CombinableMovements. The limit is about two hundred movements of the same vehicle type a day that could
+// combine with each other, which are searched through in seconds, while several hundred are not in any reasonable time.
+// Such partitions are meant to be matched within a deadline or a search budget: bundles are tried first,
+// so the first completion matches movements to bundles greedily and it is only improved upon until the interruption.
+type search struct {
+	m         *matcher
+	movements []Movement
+	conds     []*domain.ContractCondition
+	// steps counts steps of the search against its budget, zero budget meaning unlimited.
+	steps  int
+	budget int
+	// isExhaustive tells whether every combination is ranked as a whole.
+	isExhaustive bool
+	// hasCustomObjectives tells whether some objective values matches by properties which contract conditions do not check.
+	hasCustomObjectives bool
+	// classes holds input positions of movements by class number. Classes are numbered in the order the search goes through them.
+	classes [][]int
+	// groups holds by class number the first class of the group of classes linked by matches.
+	groups []int
+	// isUniform tells by class number whether completions of its group ranking the same match the same contract conditions.
+	isUniform []bool
+	// matches holds ways to match contract conditions by the first of their classes.
+	matches [][]*classMatch
+	// containing holds ways to match contract conditions by every class they take movements of.
+	containing [][]*classMatch
+	// unmatched holds by class number the rank of a movement of the class left unmatched.
+	unmatched []rank
+	// shares holds by class number the upper bound of what a movement of the class adds to the rank of a completion,
+	// multiplied by scale, so shares of matches are whole.
+	shares []rank
+	scale  int
+	// compatible tells by pair of condition numbers whether conditions could be matched along with each other.
+	// It is nil if none of conditions is exclusive.
+	compatible [][]bool
+	// capped holds by condition number the position of its instances in a search state, or -1 if the condition is not capped by MaxInstances.
+	capped   []int
+	capacity []int
+	memo     map[string][]completion
+	// winners holds the best completions found by exhaustive search.
+	winners []completion
+}
+
+// classMatch represents a way to match a contract condition to classes of movements.
+type classMatch struct {
+	condNo int
+	// classNos holds classes of matched movements in the order of contract condition movement activities.
+	classNos []int
+	// uses holds numbers of movements taken from classes, in the order of classes.
+	uses  []classUse
+	score int
+	// rank is the rank of the match, while scaled is multiplied by the search scale.
+	rank   rank
+	scaled rank
+}
+
+// classUse represents the number of movements of the class taken by a match.
+type classUse struct {
+	classNo int
+	count   int
+}
+
+// searchState represents movements left to be matched along with contract conditions still allowed to match them.
+type searchState struct {
+	// first is the number of the first class with movements left.
+	first int
+	// remaining holds numbers of movements left by class number. It is shared between states, so it is copied on change.
+	remaining []int
+	// touched is the number of classes which might have movements taken. Classes from it on have all their movements left.
+	touched int
+	// allowed tells by condition number whether the condition could still be matched. It is nil if none of conditions is exclusive.
+	allowed []bool
+	// instances holds numbers of matches of conditions capped by MaxInstances.
+	instances []int
+	// pending holds classes of movements left unmatched, which some contract condition could still match along with other movements.
+	// The state could be completed only if none of them could be matched in the end.
+	pending []classUse
+	// bound is the upper bound of what movements left add to the rank of a completion, multiplied by the search scale.
+	bound rank
+}
+
+// completion represents a way to match movements left in a search state.
+type completion struct {
+	rank  rank
+	steps *completionStep
+}
+
+// completionStep represents a match of a completion. Completions of further states are shared, so steps are linked backwards.
+type completionStep struct {
+	match *classMatch
+	next  *completionStep
+	// hash and count sum up contract conditions of the step and further ones, so completions are compared without going through them.
+	hash  uint64
+	count int
+}
+
+// newSearch returns search over movements within budget steps. All matches to the same condition share its pointer.
+// Conditions without movement activities can not match anything, so they are left out.
+func newSearch(m *matcher, movements []Movement, conds []*domain.ContractCondition, budget int) *search {
+
+	s := &search{
+		m:         m,
+		movements: movements,
+		budget:    budget,
+		memo:      map[string][]completion{},
+	}
+
+	for _, objective := range m.opts.objectives {
+		_, isConditional := objective.(conditionObjective)
+		s.isExhaustive = s.isExhaustive || !isAdditive(objective)
+		s.hasCustomObjectives = s.hasCustomObjectives || !isConditional
+	}
+
+	for _, cond := range conds {
+		if len(cond.MovementActivities) == 0 {
+			continue
+		}
+		s.conds = append(s.conds, cond)
+	}
+
+	return s
+}
+
+// interruption returns the reason why the search should stop, if any. Only its own steps count against its budget,
+// so searches of other partitions do not affect it.
+func (s *search) interruption() error {
+
+	if err := s.m.ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrPartialResult, err)
+	}
+
+	if s.budget > 0 && s.steps > s.budget {
+		s.m.isBudgetExceeded.Store(true)
+		return fmt.Errorf("%w: %w", ErrPartialResult, ErrSearchBudgetExceeded)
+	}
+
+	return nil
+}
+
+// step counts a search step and tells whether the search should stop.
+func (s *search) step() bool {
+	s.steps++
+	return s.interruption() != nil
+}
+
+// isInterrupted checks whether the search should stop due to context cancellation, deadline or exceeded search budget.
+func (s *search) isInterrupted(logger Log) bool {
+
+	if err := s.interruption(); err != nil {
+		logger.Debug("The search is interrupted: " + err.Error())
+		return true
+	}
+
+	return false
+}
+
+// run returns the best combinations, which tie if there are more than one.
+// Combinations without any match are left out.
+func (s *search) run(logger Log) [][]Match {
+
+	logger.Debug("Search invoked", slog.Any("movements", movementSet(s.movements)), slog.Any("conditions", conditionSet(s.conds)))
+
+	s.setUp(logger)
+
+	start, _ := s.settle(s.start())
+
+	completions := []completion{}
+	if s.isExhaustive {
+		s.enumerate(logger, start, nil)
+		completions = s.winners
+	} else {
+		completions = s.best(logger, start)
+	}
+
+	logger.Debug("Search states solved: " + strconv.Itoa(len(s.memo)))
+
+	combinations := [][]Match{}
+	for _, c := range completions {
+		if c.steps == nil {
+			logger.Debug("Nothing matched at all")
+			continue
+		}
+		combinations = append(combinations, s.combination(c.steps))
+	}
+
+	return combinations
+}
+
+// setUp groups movements into classes and finds ways to match contract conditions to them.
+func (s *search) setUp(logger Log) {
+
+	s.classes = s.groupMovements()
+
+	matches := []*classMatch{}
+	for condNo, cond := range s.conds {
+
+		condLogger := logger.WithFields(map[string]interface{}{"contract_condition_id": cond.Id, "contract_condition_name": cond.Name})
+
+		if s.isInterrupted(condLogger) {
+			break
+		}
+
+		condLogger.Debug("Starting to find matches for contract condition")
+		matches = append(matches, s.findMatches(condLogger, condNo)...)
+	}
+
+	// Custom objectives might value movements of merged classes differently, so classes are merged for the package ones only.
+	if !s.isExhaustive && !s.hasCustomObjectives {
+		matches = s.mergeClasses(matches)
+	}
+
+	s.orderClasses(matches)
+
+	s.matches = make([][]*classMatch, len(s.classes))
+	s.containing = make([][]*classMatch, len(s.classes))
+	s.unmatched = make([]rank, len(s.classes))
+	s.shares = make([]rank, len(s.classes))
+	s.scale = 1
+
+	for _, cm := range matches {
+
+		counts := map[int]int{}
+		for _, classNo := range cm.classNos {
+			counts[classNo]++
+		}
+		for classNo, count := range counts {
+			cm.uses = append(cm.uses, classUse{classNo: classNo, count: count})
+		}
+		sort.Slice(cm.uses, func(i, j int) bool { return cm.uses[i].classNo < cm.uses[j].classNo })
+
+		s.matches[cm.uses[0].classNo] = append(s.matches[cm.uses[0].classNo], cm)
+		for _, use := range cm.uses {
+			s.containing[use.classNo] = append(s.containing[use.classNo], cm)
+		}
+
+		if !s.isExhaustive {
+			cm.rank = s.m.rankOf([]Match{s.representative(cm)})
+			s.scale = lcm(s.scale, len(cm.classNos))
+		}
+	}
+
+	s.setUpConditions()
+
+	if !s.isExhaustive {
+		s.setUpBounds()
+		s.setUpUniformity()
+	}
+
+	logger.Debug("Movements are grouped into " + strconv.Itoa(len(s.classes)) + " classes matched " + strconv.Itoa(len(matches)) + " ways")
+}
+
+// setUpBounds sets up shares of classes in upper bounds of completions.
+// Every movement of a match is taken to add an equal share of its rank, so the bound of a class is the best share of matches
+// taking its movements, or the rank of leaving its movement unmatched.
+func (s *search) setUpBounds() {
+
+	for classNo, class := range s.classes {
+		s.unmatched[classNo] = s.m.rankOf([]Match{{Movements: []Movement{s.movements[class[0]]}}})
+		s.shares[classNo] = s.unmatched[classNo].times(s.scale)
+	}
+
+	for _, cms := range s.matches {
+		for _, cm := range cms {
+			cm.scaled = cm.rank.times(s.scale)
+			share := cm.rank.times(s.scale / len(cm.classNos))
+			for _, use := range cm.uses {
+				s.shares[use.classNo] = s.shares[use.classNo].max(share)
+			}
+		}
+	}
+}
+
+// setUpUniformity marks classes of groups whose matches are all to the same contract condition and rank the same.
+// Completions of such a group rank the same only if they match the condition as many times, so alternatives which could not
+// beat the best completion of the group do not lead to a tie either. Exclusive conditions and ones capped by MaxInstances
+// make groups depend on each other, so their groups are not marked.
+func (s *search) setUpUniformity() {
+
+	s.isUniform = make([]bool, len(s.classes))
+
+	if s.compatible != nil {
+		return
+	}
+
+	firsts := map[int]*classMatch{}
+	isBroken := map[int]bool{}
+
+	for classNo, cms := range s.matches {
+		group := s.groups[classNo]
+		for _, cm := range cms {
+			first, ok := firsts[group]
+			switch {
+			case !ok:
+				firsts[group] = cm
+				isBroken[group] = isBroken[group] || s.capped[cm.condNo] >= 0
+			case cm.condNo != first.condNo || len(cm.classNos) != len(first.classNos) || !cm.rank.isEqual(first.rank):
+				isBroken[group] = true
+			}
+		}
+	}
+
+	for classNo, group := range s.groups {
+		isBroken[group] = isBroken[group] || !s.unmatched[classNo].isEqual(s.unmatched[group])
+	}
+
+	for classNo, group := range s.groups {
+		first, ok := firsts[group]
+		if !ok || isBroken[group] {
+			continue
+		}
+		// Matching the condition once more has to change the rank, otherwise completions ranking the same could match it any number of times.
+		s.isUniform[classNo] = !first.rank.add(s.unmatched[group].times(-len(first.classNos))).isEqual(rank{})
+	}
+}
+
+// setUpConditions sets up tracking of contract conditions which are exclusive or capped by MaxInstances.
+func (s *search) setUpConditions() {
+
+	s.capped = make([]int, len(s.conds))
+	s.capacity = []int{}
+
+	isExclusive := false
+	for condNo, cond := range s.conds {
+		isExclusive = isExclusive || cond.Exclusive
+		s.capped[condNo] = -1
+		if cond.MaxInstances > 0 {
+			s.capped[condNo] = len(s.capacity)
+			s.capacity = append(s.capacity, cond.MaxInstances)
+		}
+	}
+
+	if !isExclusive {
+		return
+	}
+
+	s.compatible = make([][]bool, len(s.conds))
+	for aNo, a := range s.conds {
+		s.compatible[aNo] = make([]bool, len(s.conds))
+		for bNo, b := range s.conds {
+			s.compatible[aNo][bNo] = areCompatible(a, b)
+		}
+	}
+}
+
+// groupMovements groups movements into classes, numbered in the order of their first movements.
+// Movements of a class differ by properties which none of contract conditions tells apart, so they are interchangeable.
+// Custom scorers and objectives might tell movements apart by anything, so movements of a class differ by Id only then,
+// and every movement makes its own class for exhaustive search.
+func (s *search) groupMovements() [][]int {
+
+	_, isWeighted := s.m.opts.scorer.(*WeightedScorer)
+
+	properties := classProperties{hasAll: !isWeighted || s.hasCustomObjectives}
+	attributes := map[string]bool{}
+	for _, cond := range s.conds {
+		properties.hasDate = properties.hasDate || !cond.ValidFrom.IsZero() || !cond.ValidTo.IsZero() ||
+			cond.MaxSpan > 0 || cond.MinGap > 0 || cond.Ordered
+		for _, attribute := range cond.CoherentAttributes {
+			if !attributes[attribute] {
+				attributes[attribute] = true
+				properties.attributes = append(properties.attributes, attribute)
+			}
+		}
+	}
+
+	classes := [][]int{}
+	classNos := map[string]int{}
+
+	for mvmtNo, mvmt := range s.movements {
+
+		key := strconv.Itoa(mvmtNo)
+		if !s.isExhaustive {
+			key = properties.key(mvmt)
+		}
+
+		classNo, ok := classNos[key]
+		if !ok {
+			classNo = len(classes)
+			classNos[key] = classNo
+			classes = append(classes, nil)
+		}
+		classes[classNo] = append(classes[classNo], mvmtNo)
+	}
+
+	return classes
+}
+
+// classProperties tells which movement properties besides the ones checked by every contract condition tell movements apart.
+type classProperties struct {
+	// hasAll tells whether all properties but Id do.
+	hasAll  bool
+	hasDate bool
+	// attributes lists coherent attributes.
+	attributes []string
+}
+
+// key builds the key of movement properties which tell it apart.
+func (p classProperties) key(mvmt Movement) string {
+
+	contractor := "-"
+	if mvmt.User.Contractor != nil {
+		contractor = "+" + *mvmt.User.Contractor
+	}
+
+	values := []string{mvmt.Type, mvmt.Option, mvmt.Branch.Id, mvmt.Workflow.Type, mvmt.Workflow.Factor, contractor, mvmt.Vehicle.Type}
+
+	if p.hasAll || p.hasDate {
+		values = append(values, strconv.FormatInt(mvmt.Date.UnixNano(), 10), mvmt.Date.Location().String())
+	}
+
+	if p.hasAll {
+		return strings.Join(append(values, mvmt.Workflow.Id, mvmt.User.Id, mvmt.Vehicle.Id), "\x00")
+	}
+
+	for _, attribute := range p.attributes {
+		value, _ := coherentAttributeValue(mvmt, attribute)
+		values = append(values, value)
+	}
+
+	return strings.Join(values, "\x00")
+}
+
+// findMatches returns ways to match the contract condition to classes, each multiset of classes once with its best score.
+// Movements which could be matched together only if they are coherent are assigned to the condition group by group.
+func (s *search) findMatches(logger Log, condNo int) []*classMatch {
+
+	cond := s.conds[condNo]
+
+	// The condition takes at most as many movements of a class as it matches at all, so the rest of them are not tried.
+	perClass := maxMovements(cond)
+
+	// Movements of other types fit none of the condition movement activities, so they are not tried.
+	types := map[string]bool{}
+	for _, ccma := range cond.MovementActivities {
+		types[ccma.Type] = true
+	}
+
+	groups := [][]int{}
+	groupNos := map[string]int{}
+	classOf := map[int]int{}
+
+	for classNo, class := range s.classes {
+		if !types[s.movements[class[0]].Type] {
+			continue
+		}
+		for _, mvmtNo := range class[:min(len(class), perClass)] {
+
+			values := make([]string, len(cond.CoherentAttributes))
+			for attributeNo, attribute := range cond.CoherentAttributes {
+				values[attributeNo], _ = coherentAttributeValue(s.movements[mvmtNo], attribute)
+			}
+			key := strings.Join(values, "\x00")
+
+			groupNo, ok := groupNos[key]
+			if !ok {
+				groupNo = len(groups)
+				groupNos[key] = groupNo
+				groups = append(groups, nil)
+			}
+			groups[groupNo] = append(groups[groupNo], mvmtNo)
+			classOf[mvmtNo] = classNo
+		}
+	}
+
+	result := []*classMatch{}
+	resultNos := map[string]int{}
+
+	for _, group := range groups {
+
+		movements := make([]Movement, len(group))
+		for position, mvmtNo := range group {
+			movements[position] = s.movements[mvmtNo]
+		}
+
+		isDebug := isDebugEnabled(logger)
+
+		isComplete := s.m.forEachAssignment(logger, movements, cond, s.step, func(assignment []int, score int) {
+
+			mvmts := make([]Movement, len(assignment))
+			classNos := make([]int, len(assignment))
+			for no, position := range assignment {
+				mvmts[no] = movements[position]
+				classNos[no] = classOf[group[position]]
+			}
+
+			if reason := s.m.checkBundle(cond, mvmts); reason != RejectionReasonNone {
+				if isDebug {
+					logger.Debug("Assignment does not satisfy bundle constraints (" + string(reason) + "): " + classSetKey(classNos))
+				}
+				return
+			}
+
+			key := classSetKey(classNos)
+			if resultNo, ok := resultNos[key]; ok {
+				if result[resultNo].score < score {
+					if isDebug {
+						logger.Debug("Found better assignment for the same classes: " + key)
+					}
+					result[resultNo].classNos = classNos
+					result[resultNo].score = score
+				}
+				return
+			}
+
+			if isDebug {
+				logger.Debug("Found new assignment: " + key)
+			}
+			resultNos[key] = len(result)
+			result = append(result, &classMatch{condNo: condNo, classNos: classNos, score: score})
+		})
+
+		if !isComplete {
+			break
+		}
+	}
+
+	return result
+}
+
+// mergeClasses merges classes which could be swapped for each other in every match, so their movements are interchangeable
+// for contract conditions, even though they differ by properties, e.g. by dates within MaxSpan of all the others.
+// Classes giving more than one movement to a match are left as they are, as movements of merged classes might not make such a match.
+// Returns matches to merged classes, each multiset of them once.
+func (s *search) mergeClasses(matches []*classMatch) []*classMatch {
+
+	// The signature of a class lists its matches along with the rest of their classes.
+	signatures := make([][]string, len(s.classes))
+	isMergeable := make([]bool, len(s.classes))
+	for classNo := range isMergeable {
+		isMergeable[classNo] = true
+	}
+
+	for _, cm := range matches {
+		for no, classNo := range cm.classNos {
+
+			others := append(append([]int{}, cm.classNos[:no]...), cm.classNos[no+1:]...)
+			for _, otherNo := range others {
+				if otherNo == classNo {
+					isMergeable[classNo] = false
+				}
+			}
+
+			signatures[classNo] = append(signatures[classNo], strconv.Itoa(cm.condNo)+":"+strconv.Itoa(cm.score)+":"+classSetKey(others))
+		}
+	}
+
+	classes := [][]int{}
+	newNos := make([]int, len(s.classes))
+	newNoBySignature := map[string]int{}
+
+	for classNo, class := range s.classes {
+
+		if isMergeable[classNo] {
+			sort.Strings(signatures[classNo])
+			signature := strings.Join(signatures[classNo], ";")
+			if newNo, ok := newNoBySignature[signature]; ok {
+				newNos[classNo] = newNo
+				classes[newNo] = append(classes[newNo], class...)
+				continue
+			}
+			newNoBySignature[signature] = len(classes)
+		}
+
+		newNos[classNo] = len(classes)
+		classes = append(classes, append([]int{}, class...))
+	}
+
+	if len(classes) == len(s.classes) {
+		return matches
+	}
+
+	for _, class := range classes {
+		sort.Ints(class)
+	}
+	s.classes = classes
+
+	result := []*classMatch{}
+	isFound := map[string]bool{}
+
+	for _, cm := range matches {
+
+		for no, classNo := range cm.classNos {
+			cm.classNos[no] = newNos[classNo]
+		}
+
+		key := strconv.Itoa(cm.condNo) + ":" + classSetKey(cm.classNos)
+		if !isFound[key] {
+			isFound[key] = true
+			result = append(result, cm)
+		}
+	}
+
+	return result
+}
+
+// classSetKey builds an order independent key of class numbers.
+func classSetKey(classNos []int) string {
+
+	sorted := append([]int{}, classNos...)
+	sort.Ints(sorted)
+
+	key := ""
+	for _, classNo := range sorted {
+		key += strconv.Itoa(classNo) + ","
+	}
+	return key
+}
+
+// orderClasses renumbers classes, so classes linked by matches go one after another.
+// Groups of linked classes go in the order of their first movements, as well as classes within a group.
+func (s *search) orderClasses(matches []*classMatch) {
+
+	// Classes are linked by union-find, where the root of a group is its first class.
+	parents := make([]int, len(s.classes))
+	for classNo := range parents {
+		parents[classNo] = classNo
+	}
+	var root func(classNo int) int
+	root = func(classNo int) int {
+		if parents[classNo] != classNo {
+			parents[classNo] = root(parents[classNo])
+		}
+		return parents[classNo]
+	}
+
+	for _, cm := range matches {
+		for _, classNo := range cm.classNos[1:] {
+			a, b := root(cm.classNos[0]), root(classNo)
+			parents[max(a, b)] = min(a, b)
+		}
+	}
+
+	order := make([]int, len(s.classes))
+	for classNo := range order {
+		order[classNo] = classNo
+	}
+	sort.SliceStable(order, func(i, j int) bool { return root(order[i]) < root(order[j]) })
+
+	classes := make([][]int, len(s.classes))
+	newNos := make([]int, len(s.classes))
+	for newNo, classNo := range order {
+		classes[newNo] = s.classes[classNo]
+		newNos[classNo] = newNo
+	}
+	s.classes = classes
+
+	s.groups = make([]int, len(s.classes))
+	for classNo, newNo := range newNos {
+		s.groups[newNo] = newNos[root(classNo)]
+	}
+
+	for _, cm := range matches {
+		for no, classNo := range cm.classNos {
+			cm.classNos[no] = newNos[classNo]
+		}
+	}
+}
+
+// representative returns the match of first movements of its classes.
+func (s *search) representative(cm *classMatch) Match {
+
+	match := Match{ContractCondition: s.conds[cm.condNo], Score: cm.score, Movements: make([]Movement, len(cm.classNos))}
+
+	taken := map[int]int{}
+	for no, classNo := range cm.classNos {
+		match.Movements[no] = s.movements[s.classes[classNo][taken[classNo]]]
+		taken[classNo]++
+	}
+
+	return match
+}
+
+// start returns the state with all movements left.
+func (s *search) start() searchState {
+
+	st := searchState{
+		remaining: make([]int, len(s.classes)),
+		instances: make([]int, len(s.capacity)),
+	}
+
+	for classNo, class := range s.classes {
+		st.remaining[classNo] = len(class)
+		st.bound = st.bound.add(s.shares[classNo].times(len(class)))
+	}
+
+	if s.compatible != nil {
+		st.allowed = make([]bool, len(s.conds))
+		for condNo := range st.allowed {
+			st.allowed[condNo] = true
+		}
+	}
+
+	return st
+}
+
+// best returns the best completions of the state.
+// If the search is interrupted, the first alternatives are followed until any completion is found, which is returned then.
+func (s *search) best(logger Log, st searchState) []completion {
+
+	if st.first == len(s.classes) {
+		return []completion{{}}
+	}
+
+	key := s.stateKey(st)
+	if completions, ok := s.memo[key]; ok {
+		logger.Debug("Search state has been solved before")
+		return completions
+	}
+
+	winners := []completion{}
+
+	for _, cm := range s.alternatives(st) {
+
+		s.steps++
+		if len(winners) > 0 && s.isInterrupted(logger) {
+			break
+		}
+
+		next, gain, ok := s.follow(st, cm)
+		if !ok {
+			logger.Debug("Movements left unmatched could still be matched. Skipping the alternative")
+			continue
+		}
+
+		if len(winners) > 0 {
+			bound, best := gain.times(s.scale).add(next.bound), winners[0].rank.times(s.scale)
+			if best.isBetter(bound) || s.isUniform[st.first] && !bound.isBetter(best) {
+				logger.Debug("Alternative could not beat the best completion, so it is pruned")
+				continue
+			}
+		}
+
+		for _, sub := range s.best(logger, next) {
+
+			// Combining completions is what takes time once states are solved, so the interruption is checked here as well.
+			if len(winners) > 0 && s.interruption() != nil {
+				break
+			}
+
+			c := completion{rank: gain.add(sub.rank), steps: sub.steps}
+			if cm != nil {
+				c.steps = push(cm, sub.steps)
+			}
+			winners = s.keepBest(logger, winners, c)
+		}
+	}
+
+	// Completions of an interrupted search might be incomplete, so they are not memoized. No completion at all is exact though,
+	// as alternatives are skipped only once there is one, and it is memoized, so dead ends are not searched through again.
+	if len(winners) == 0 || s.interruption() == nil {
+		s.memo[key] = winners
+	}
+
+	return winners
+}
+
+// enumerate ranks every completion of the state as a whole and keeps the best ones in winners.
+// If the search is interrupted, the first alternatives are followed until any completion is found.
+func (s *search) enumerate(logger Log, st searchState, steps *completionStep) {
+
+	if st.first == len(s.classes) {
+		s.winners = s.keepBest(logger, s.winners, completion{rank: s.m.rankOf(s.combination(steps)), steps: steps})
+		return
+	}
+
+	for _, cm := range s.alternatives(st) {
+
+		s.steps++
+		if len(s.winners) > 0 && s.isInterrupted(logger) {
+			return
+		}
+
+		next, _, ok := s.follow(st, cm)
+		if !ok {
+			continue
+		}
+
+		nextSteps := steps
+		if cm != nil {
+			nextSteps = push(cm, steps)
+		}
+		s.enumerate(logger, next, nextSteps)
+	}
+}
+
+// alternatives returns ways to match a movement of the first class left, followed by nil, which stands for leaving it unmatched.
+// Bundles go before single activity conditions, so the first completion, which is all there is if the search is interrupted,
+// matches movements to bundles wherever it could.
+func (s *search) alternatives(st searchState) []*classMatch {
+
+	bundles := []*classMatch{}
+	singles := []*classMatch{}
+
+	for _, cm := range s.matches[st.first] {
+		if !s.isApplicable(st, cm) {
+			continue
+		}
+		if isBundle(s.conds[cm.condNo]) {
+			bundles = append(bundles, cm)
+		} else {
+			singles = append(singles, cm)
+		}
+	}
+
+	return append(append(bundles, singles...), nil)
+}
+
+// follow returns the state following the match, or leaving the movement of the first class unmatched if cm is nil,
+// along with the rank it adds to completions. Returns false if the state could not be completed.
+func (s *search) follow(st searchState, cm *classMatch) (searchState, rank, bool) {
+
+	next := st
+	next.remaining = append([]int{}, st.remaining...)
+
+	if cm == nil {
+		next.remaining[st.first]--
+		next.bound = next.bound.add(s.shares[st.first].times(-1))
+		next.touched = max(next.touched, st.first+1)
+		next.pending = append([]classUse{}, st.pending...)
+		if last := len(next.pending) - 1; last >= 0 && next.pending[last].classNo == st.first {
+			next.pending[last].count++
+		} else {
+			next.pending = append(next.pending, classUse{classNo: st.first, count: 1})
+		}
+
+		next, ok := s.settle(next)
+		return next, s.unmatched[st.first], ok
+	}
+
+	for _, use := range cm.uses {
+		next.remaining[use.classNo] -= use.count
+		next.bound = next.bound.add(s.shares[use.classNo].times(-use.count))
+		next.touched = max(next.touched, use.classNo+1)
+	}
+
+	if st.allowed != nil {
+		next.allowed = make([]bool, len(st.allowed))
+		for condNo := range next.allowed {
+			next.allowed[condNo] = st.allowed[condNo] && s.compatible[cm.condNo][condNo]
+		}
+	}
+
+	if capNo := s.capped[cm.condNo]; capNo >= 0 {
+		next.instances = append([]int{}, st.instances...)
+		next.instances[capNo]++
+	}
+
+	next, ok := s.settle(next)
+	return next, cm.rank, ok
+}
+
+// settle moves the state to the first class with movements left and drops pending classes which could not be matched anymore.
+// Returns false if some pending class could be matched in the end for sure.
+func (s *search) settle(st searchState) (searchState, bool) {
+
+	for st.first < len(s.classes) && st.remaining[st.first] == 0 {
+		st.first++
+	}
+
+	if len(st.pending) == 0 {
+		return st, true
+	}
+
+	pending := make([]classUse, 0, len(st.pending))
+	for _, p := range st.pending {
+
+		isPending := false
+		for _, cm := range s.containing[p.classNo] {
+			if !s.isFeasible(st, cm) {
+				continue
+			}
+			isPending = true
+			// Movements of classes before the first one stay as they are, so the match stays feasible,
+			// unless the condition could get suppressed or capped.
+			if (st.first == len(s.classes) || st.allowed == nil && s.capped[cm.condNo] < 0) && cm.uses[len(cm.uses)-1].classNo < st.first {
+				return st, false
+			}
+		}
+
+		if isPending {
+			pending = append(pending, p)
+		}
+	}
+	st.pending = pending
+
+	return st, true
+}
+
+// isAllowed tells whether the contract condition of the match is neither suppressed nor capped in the state.
+func (s *search) isAllowed(st searchState, cm *classMatch) bool {
+
+	if st.allowed != nil && !st.allowed[cm.condNo] {
+		return false
+	}
+
+	capNo := s.capped[cm.condNo]
+	return capNo < 0 || st.instances[capNo] < s.capacity[capNo]
+}
+
+// isApplicable tells whether the match could take movements left in the state.
+func (s *search) isApplicable(st searchState, cm *classMatch) bool {
+
+	if !s.isAllowed(st, cm) {
+		return false
+	}
+
+	for _, use := range cm.uses {
+		if st.remaining[use.classNo] < use.count {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isFeasible tells whether the match could take movements left or pending in the state.
+func (s *search) isFeasible(st searchState, cm *classMatch) bool {
+
+	if !s.isAllowed(st, cm) {
+		return false
+	}
+
+	for _, use := range cm.uses {
+		available := st.remaining[use.classNo]
+		for _, p := range st.pending {
+			if p.classNo == use.classNo {
+				available += p.count
+			}
+		}
+		if available < use.count {
+			return false
+		}
+	}
+
+	return true
+}
+
+// stateKey builds the key of the search state. Classes after touched ones have all their movements left, so they are left out.
+func (s *search) stateKey(st searchState) string {
+
+	touched := st.touched
+	for touched > st.first && st.remaining[touched-1] == len(s.classes[touched-1]) {
+		touched--
+	}
+
+	key := make([]byte, 0, 2*binary.MaxVarintLen64+max(touched-st.first, 0)+len(st.allowed)/8+len(st.instances)+2*len(st.pending)+1)
+	key = binary.AppendUvarint(key, uint64(st.first))
+	key = binary.AppendUvarint(key, uint64(max(touched-st.first, 0)))
+	for classNo := st.first; classNo < touched; classNo++ {
+		key = binary.AppendUvarint(key, uint64(st.remaining[classNo]))
+	}
+
+	if st.allowed != nil {
+		bits := make([]byte, (len(st.allowed)+7)/8)
+		for condNo, isAllowed := range st.allowed {
+			if isAllowed {
+				bits[condNo/8] |= 1 << (condNo % 8)
+			}
+		}
+		key = append(key, bits...)
+	}
+
+	for _, instances := range st.instances {
+		key = binary.AppendUvarint(key, uint64(instances))
+	}
+
+	key = binary.AppendUvarint(key, uint64(len(st.pending)))
+	for _, p := range st.pending {
+		key = binary.AppendUvarint(key, uint64(p.classNo))
+		key = binary.AppendUvarint(key, uint64(p.count))
+	}
+
+	return string(key)
+}
+
+// keepBest adds the completion to winners, if it ranks not lower than them.
+// Completions matching the same contract conditions as one of winners with the same rank are interchangeable,
+// so the first found one is kept.
+func (s *search) keepBest(logger Log, winners []completion, c completion) []completion {
+
+	switch {
+	case len(winners) == 0 || c.rank.isBetter(winners[0].rank):
+		logger.Debug("Completion is better than any in before. Keeping it as the only winner")
+		return []completion{c}
+	case !c.rank.isEqual(winners[0].rank):
+		return winners
+	}
+
+	for _, winner := range winners {
+		if haveSameSteps(winner.steps, c.steps) {
+			logger.Debug("Completion has same rank and contract conditions as some in before. Skipping it")
+			return winners
+		}
+	}
+
+	logger.Debug("Completion has same rank as some in before. Adding to winners")
+	return append(winners, c)
+}
+
+// push returns the step of the match followed by steps.
+func push(cm *classMatch, steps *completionStep) *completionStep {
+
+	step := &completionStep{match: cm, next: steps, hash: conditionHash(cm.condNo), count: 1}
+	if steps != nil {
+		step.hash += steps.hash
+		step.count += steps.count
+	}
+
+	return step
+}
+
+// conditionHash mixes bits of the condition number, so sums of hashes of different conditions rarely collide.
+func conditionHash(condNo int) uint64 {
+
+	hash := uint64(condNo) + 0x9e3779b97f4a7c15
+	hash = (hash ^ hash>>30) * 0xbf58476d1ce4e5b9
+	hash = (hash ^ hash>>27) * 0x94d049bb133111eb
+	return hash ^ hash>>31
+}
+
+// haveSameSteps checks whether both steps along with further ones match the same contract conditions.
+func haveSameSteps(a, b *completionStep) bool {
+
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	if a.count != b.count || a.hash != b.hash {
+		return false
+	}
+
+	// Steps of both go in lockstep, as there are as many of them, so shared further steps are not gone through.
+	counts := map[int]int{}
+	for ; a != b; a, b = a.next, b.next {
+		counts[a.match.condNo]++
+		counts[b.match.condNo]--
+	}
+
+	for _, count := range counts {
+		if count != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// combination returns matches of steps, with unmatched movements as a Match without ContractCondition.
+// Matches take movements of their classes in input order, and go in the order of their first movements.
+func (s *search) combination(steps *completionStep) []Match {
+
+	taken := make([]int, len(s.classes))
+	matches := []Match{}
+	firstNos := []int{}
+
+	for step := steps; step != nil; step = step.next {
+
+		cm := step.match
+		match := Match{ContractCondition: s.conds[cm.condNo], Score: cm.score, Movements: make([]Movement, len(cm.classNos))}
+		firstNo := len(s.movements)
+
+		for no, classNo := range cm.classNos {
+			mvmtNo := s.classes[classNo][taken[classNo]]
+			taken[classNo]++
+			match.Movements[no] = s.movements[mvmtNo]
+			firstNo = min(firstNo, mvmtNo)
+		}
+
+		matches = append(matches, match)
+		firstNos = append(firstNos, firstNo)
+	}
+
+	order := make([]int, len(matches))
+	for matchNo := range order {
+		order[matchNo] = matchNo
+	}
+	sort.Slice(order, func(i, j int) bool { return firstNos[order[i]] < firstNos[order[j]] })
+
+	combination := make([]Match, 0, len(matches)+1)
+	for _, matchNo := range order {
+		combination = append(combination, matches[matchNo])
+	}
+
+	leftoverNos := []int{}
+	for classNo, class := range s.classes {
+		leftoverNos = append(leftoverNos, class[taken[classNo]:]...)
+	}
+	sort.Ints(leftoverNos)
+
+	if len(leftoverNos) > 0 {
+		leftovers := make([]Movement, len(leftoverNos))
+		for position, mvmtNo := range leftoverNos {
+			leftovers[position] = s.movements[mvmtNo]
+		}
+		combination = append(combination, Match{Movements: leftovers})
+	}
+
+	return combination
+}
+
+// lcm returns the least common multiple of a and b.
+func lcm(a, b int) int {
+
+	gcd := a
+	for rest := b; rest != 0; {
+		gcd, rest = rest, gcd%rest
+	}
+
+	return a / gcd * b
+}
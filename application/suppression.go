@@ -0,0 +1,42 @@
+package application
+
+import "github.com/ivan-kostko/nrute-matches/domain"
+
+// suppresses tells whether matching the exclusive contract condition a rules out matching the contract condition b.
+// An exclusive condition without Suppresses rules out all other conditions, including other versions of its own and ones of the same Id.
+// Conditions are told apart by pointer, so a and b have to point into the same copy of conditions.
+func suppresses(a, b *domain.ContractCondition) bool {
+
+	if !a.Exclusive || a == b {
+		return false
+	}
+
+	if len(a.Suppresses) == 0 {
+		return true
+	}
+
+	for _, id := range a.Suppresses {
+		if id == b.Id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// areCompatible tells whether both contract conditions could be matched in the same combination.
+func areCompatible(a, b *domain.ContractCondition) bool {
+	return !suppresses(a, b) && !suppresses(b, a)
+}
+
+// isSuppressedBy tells whether the contract condition is suppressed by any contract condition matched by matches.
+func isSuppressedBy(cond *domain.ContractCondition, matches []Match) bool {
+
+	for _, match := range matches {
+		if match.ContractCondition != nil && !areCompatible(match.ContractCondition, cond) {
+			return true
+		}
+	}
+
+	return false
+}
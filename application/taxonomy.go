@@ -0,0 +1,44 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// VehicleTaxonomy maps vehicle types to their parent types, e.g. van to light-commercial and light-commercial to any.
+// A contract condition for a parent type matches movements of its descendant types.
+type VehicleTaxonomy map[string]string
+
+// ParseVehicleTaxonomy parses JSON encoded taxonomy, e.g. loaded from config.
+// Returns ErrInvalidTaxonomy if some vehicle type turns out to be its own ancestor.
+func ParseVehicleTaxonomy(data []byte) (VehicleTaxonomy, error) {
+
+	taxonomy := VehicleTaxonomy{}
+
+	if err := json.Unmarshal(data, &taxonomy); err != nil {
+		return nil, err
+	}
+
+	for vehicleType := range taxonomy {
+		for _, ancestor := range taxonomy.ancestors(vehicleType) {
+			if ancestor == vehicleType {
+				return nil, fmt.Errorf("%w: vehicle type %q is its own ancestor", ErrInvalidTaxonomy, vehicleType)
+			}
+		}
+	}
+
+	return taxonomy, nil
+}
+
+// ancestors returns ancestors of the vehicle type, starting with its parent.
+// The walk stops after as many steps as there are types in taxonomy, so cycles do not loop forever.
+func (t VehicleTaxonomy) ancestors(vehicleType string) []string {
+
+	result := []string{}
+
+	for parent, ok := t[vehicleType]; ok && len(result) < len(t); parent, ok = t[parent] {
+		result = append(result, parent)
+	}
+
+	return result
+}
@@ -0,0 +1,48 @@
+package application_test
+
+import (
+	"testing"
+
+	"github.com/ivan-kostko/nrute-matches/application"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVehicleTaxonomy(t *testing.T) {
+
+	testCases := []struct {
+		Alias            string
+		DataIn           string
+		ExpectedTaxonomy application.VehicleTaxonomy
+		ExpectedErr      error
+	}{
+		{
+			Alias:            `Chain of parent types`,
+			DataIn:           `{"van": "light-commercial", "light-commercial": "any"}`,
+			ExpectedTaxonomy: application.VehicleTaxonomy{"van": "light-commercial", "light-commercial": "any"},
+		},
+		{
+			Alias:       `Vehicle type is its own ancestor`,
+			DataIn:      `{"van": "light-commercial", "light-commercial": "van"}`,
+			ExpectedErr: application.ErrInvalidTaxonomy,
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			actualTaxonomy, err := application.ParseVehicleTaxonomy([]byte(tCase.DataIn))
+
+			if tCase.ExpectedErr != nil {
+				assert.ErrorIs(t, err, tCase.ExpectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tCase.ExpectedTaxonomy, actualTaxonomy)
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
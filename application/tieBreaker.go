@@ -0,0 +1,132 @@
+package application
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ivan-kostko/nrute-matches/domain"
+)
+
+// TieBreaker narrows down combinations sharing the best score to the preferred ones.
+// It returns the subset of tied combinations which are still equally good by its criteria.
+type TieBreaker func(tied [][]Match) [][]Match
+
+// TieBreakFewerBundles prefers combinations with fewer matched contract conditions.
+func TieBreakFewerBundles() TieBreaker {
+	return preferLowest(func(combination []Match) int {
+		return len(conditionsOf(combination))
+	})
+}
+
+// TieBreakMoreSpecificConditions prefers combinations of contract conditions which define more attributes
+// instead of relying on fallbacks, and define them by exact values rather than sets or wildcards.
+func TieBreakMoreSpecificConditions() TieBreaker {
+	return preferLowest(func(combination []Match) int {
+		specificity := 0
+		for _, cond := range conditionsOf(combination) {
+			specificity += conditionSpecificity(cond)
+		}
+		return -specificity
+	})
+}
+
+// TieBreakConditionPriority prefers combinations with the higher total priority of contract conditions.
+func TieBreakConditionPriority(priority func(cond *domain.ContractCondition) int) TieBreaker {
+	return preferLowest(func(combination []Match) int {
+		total := 0
+		for _, cond := range conditionsOf(combination) {
+			total += priority(cond)
+		}
+		return -total
+	})
+}
+
+// TieBreakLowestConditionId prefers the combination whose sorted contract condition Ids are lexicographically the lowest.
+func TieBreakLowestConditionId() TieBreaker {
+	return func(tied [][]Match) [][]Match {
+
+		winners := [][]Match{}
+		lowest := ""
+
+		for combinationNo, combination := range tied {
+			ids := []string{}
+			for _, cond := range conditionsOf(combination) {
+				ids = append(ids, cond.Id)
+			}
+			sort.Strings(ids)
+
+			// Zero byte separator keeps shorter id lists lower than longer ones with the same prefix.
+			k := strings.Join(ids, "\x00")
+
+			if combinationNo == 0 || k < lowest {
+				winners = [][]Match{combination}
+				lowest = k
+				continue
+			}
+			if k == lowest {
+				winners = append(winners, combination)
+			}
+		}
+
+		return winners
+	}
+}
+
+// breakTie applies tie breakers in order until just one combination is left.
+func breakTie(tied [][]Match, tieBreakers []TieBreaker) [][]Match {
+	for _, tieBreaker := range tieBreakers {
+		if len(tied) < 2 {
+			break
+		}
+		tied = tieBreaker(tied)
+	}
+	return tied
+}
+
+// preferLowest returns a TieBreaker keeping combinations with the lowest key.
+func preferLowest(key func(combination []Match) int) TieBreaker {
+	return func(tied [][]Match) [][]Match {
+
+		winners := [][]Match{}
+		lowest := 0
+
+		for combinationNo, combination := range tied {
+			k := key(combination)
+			if combinationNo == 0 || k < lowest {
+				winners = [][]Match{combination}
+				lowest = k
+				continue
+			}
+			if k == lowest {
+				winners = append(winners, combination)
+			}
+		}
+
+		return winners
+	}
+}
+
+// conditionsOf returns contract conditions matched in the combination.
+func conditionsOf(combination []Match) []*domain.ContractCondition {
+	conds := []*domain.ContractCondition{}
+	for _, match := range combination {
+		if match.ContractCondition != nil {
+			conds = append(conds, match.ContractCondition)
+		}
+	}
+	return conds
+}
+
+// conditionSpecificity sums up specificity of contract condition attribute patterns,
+// so exact values outrank sets, sets outrank wildcards and any of them outranks undefined attributes.
+func conditionSpecificity(cond *domain.ContractCondition) int {
+
+	specificity := patternSpecificity(cond.VehicleType, domain.Undefined_VehicleType)
+	specificity += patternSpecificity(cond.WorkflowFactor, domain.Undefined_WorkflowFactor)
+
+	for _, ccma := range cond.MovementActivities {
+		specificity += patternSpecificity(ccma.Option, domain.Undefined_MovementOption)
+	}
+
+	return specificity
+}
@@ -0,0 +1,304 @@
+package application
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ivan-kostko/nrute-matches/domain"
+)
+
+// ValidationError represents a single problem found by Validator.
+type ValidationError struct {
+	// Subject is either "contract condition" or "movement".
+	Subject string
+	// Index is the position of the invalid item in the validated slice.
+	Index int
+	// Id is the Id of the invalid item.
+	Id string
+	// Field is the name of the invalid field.
+	Field string
+	// Problem describes what is wrong with the field.
+	Problem string
+	// Err is the sentinel error the problem falls under, e.g. ErrInvalidCondition.
+	Err error
+}
+
+func (e ValidationError) Error() string {
+	return e.Subject + " #" + strconv.Itoa(e.Index) + " (Id " + strconv.Quote(e.Id) + "): " + e.Field + " " + e.Problem
+}
+
+func (e ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors holds all problems found by Validator.
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap makes errors.Is and errors.As look into every problem.
+func (errs ValidationErrors) Unwrap() []error {
+	unwrapped := make([]error, 0, len(errs))
+	for _, e := range errs {
+		unwrapped = append(unwrapped, e)
+	}
+	return unwrapped
+}
+
+// Validator checks contract conditions and movements, e.g. at import time, before they reach the matcher.
+// It reports all problems at once instead of stopping at the first one.
+type Validator struct {
+	movementTypes map[string]bool
+}
+
+// NewValidator returns Validator with the catalogue of known movement types.
+// Without registered movement types any non empty type is accepted.
+func NewValidator(movementTypes ...string) *Validator {
+	v := &Validator{movementTypes: map[string]bool{}}
+	v.RegisterMovementTypes(movementTypes...)
+	return v
+}
+
+// RegisterMovementTypes adds movement types to the catalogue of known ones.
+func (v *Validator) RegisterMovementTypes(movementTypes ...string) {
+	for _, movementType := range movementTypes {
+		v.movementTypes[movementType] = true
+	}
+}
+
+// ValidateConditions checks contract conditions.
+// Returns ValidationErrors with all found problems, or nil if there are none.
+func (v *Validator) ValidateConditions(conds []domain.ContractCondition) error {
+
+	errs := ValidationErrors{}
+
+	report := func(condNo int, cond domain.ContractCondition, field, problem string, err error) {
+		errs = append(errs, ValidationError{Subject: "contract condition", Index: condNo, Id: cond.Id, Field: field, Problem: problem, Err: err})
+	}
+
+	// Previously seen versions of contract conditions by Id
+	seenVersions := map[string][]domain.ContractCondition{}
+
+	// Ids of all contract conditions, as suppressed ones might come later
+	knownIds := map[string]bool{}
+	for _, cond := range conds {
+		knownIds[cond.Id] = true
+	}
+
+	for condNo, cond := range conds {
+
+		if cond.Id == "" {
+			report(condNo, cond, "Id", "is empty", ErrInvalidCondition)
+		} else {
+			for _, seen := range seenVersions[cond.Id] {
+				if seen.Version == cond.Version {
+					report(condNo, cond, "Id", "is used by another contract condition of version "+strconv.Itoa(cond.Version), ErrDuplicateCondition)
+					break
+				}
+				if validityOverlaps(seen, cond) {
+					report(condNo, cond, "Version", "validity period overlaps with version "+strconv.Itoa(seen.Version), ErrDuplicateCondition)
+					break
+				}
+			}
+			seenVersions[cond.Id] = append(seenVersions[cond.Id], cond)
+		}
+
+		if !cond.ValidFrom.IsZero() && !cond.ValidTo.IsZero() && !wallClock(cond.ValidTo).After(wallClock(cond.ValidFrom)) {
+			report(condNo, cond, "ValidTo", "is not after ValidFrom", ErrInvalidCondition)
+		}
+
+		if cond.MaxSpan < 0 {
+			report(condNo, cond, "MaxSpan", "is negative", ErrInvalidCondition)
+		}
+
+		if cond.MinGap < 0 {
+			report(condNo, cond, "MinGap", "is negative", ErrInvalidCondition)
+		}
+
+		if cond.MaxInstances < 0 {
+			report(condNo, cond, "MaxInstances", "is negative", ErrInvalidCondition)
+		}
+
+		if cond.OrderTolerance < 0 {
+			report(condNo, cond, "OrderTolerance", "is negative", ErrInvalidCondition)
+		}
+
+		for attrNo, attribute := range cond.CoherentAttributes {
+			if _, ok := coherentAttributeValue(Movement{}, attribute); !ok {
+				report(condNo, cond, "CoherentAttributes["+strconv.Itoa(attrNo)+"]", strconv.Quote(attribute)+" is unknown", ErrInvalidCondition)
+			}
+		}
+
+		if len(cond.Suppresses) > 0 && !cond.Exclusive {
+			report(condNo, cond, "Suppresses", "is set for non exclusive contract condition", ErrInvalidCondition)
+		}
+
+		for suppressedNo, suppressedId := range cond.Suppresses {
+			if !knownIds[suppressedId] {
+				report(condNo, cond, "Suppresses["+strconv.Itoa(suppressedNo)+"]", strconv.Quote(suppressedId)+" is unknown contract condition", ErrInvalidCondition)
+			}
+		}
+
+		priceAmounts := priceModelAmounts(cond.Price)
+		for _, field := range sortedKeys(priceAmounts) {
+			if _, err := parseAmount(&cond, priceAmounts[field]); err != nil {
+				report(condNo, cond, "Price."+field, strconv.Quote(priceAmounts[field])+" is malformed amount", ErrInvalidPrice)
+			}
+		}
+
+		if len(priceAmounts) > 0 && cond.Price.Currency == "" {
+			report(condNo, cond, "Price.Currency", "is empty", ErrInvalidPrice)
+		}
+
+		if cond.BranchIdentifier == "" {
+			report(condNo, cond, "BranchIdentifier", "is empty", ErrInvalidCondition)
+		}
+
+		if !isValidPattern(cond.VehicleType) {
+			report(condNo, cond, "VehicleType", strconv.Quote(cond.VehicleType)+" is malformed pattern", ErrInvalidCondition)
+		}
+
+		if !isValidPattern(cond.WorkflowFactor) {
+			report(condNo, cond, "WorkflowFactor", strconv.Quote(cond.WorkflowFactor)+" is malformed pattern", ErrInvalidCondition)
+		}
+
+		if len(cond.MovementActivities) == 0 {
+			report(condNo, cond, "MovementActivities", "are empty", ErrInvalidCondition)
+		}
+
+		for maNo, ccma := range cond.MovementActivities {
+			field := "MovementActivities[" + strconv.Itoa(maNo) + "].Type"
+			switch {
+			case ccma.Type == "":
+				report(condNo, cond, field, "is empty", ErrInvalidCondition)
+			case !v.isKnownMovementType(ccma.Type):
+				report(condNo, cond, field, strconv.Quote(ccma.Type)+" is unknown", ErrInvalidCondition)
+			}
+
+			field = "MovementActivities[" + strconv.Itoa(maNo) + "]"
+			if !isValidPattern(ccma.Option) {
+				report(condNo, cond, field+".Option", strconv.Quote(ccma.Option)+" is malformed pattern", ErrInvalidCondition)
+			}
+
+			switch {
+			case ccma.Min < 0:
+				report(condNo, cond, field+".Min", "is negative", ErrInvalidCondition)
+			case ccma.Max < 0:
+				report(condNo, cond, field+".Max", "is negative", ErrInvalidCondition)
+			case ccma.Max > 0 && ccma.Max < ccma.Min:
+				report(condNo, cond, field+".Max", "is less than Min", ErrInvalidCondition)
+			case ccma.Optional && ccma.Min > 0:
+				report(condNo, cond, field+".Min", "is set for optional activity", ErrInvalidCondition)
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// ValidateMovements checks movements.
+// Returns ValidationErrors with all found problems, or nil if there are none.
+func (v *Validator) ValidateMovements(movements []Movement) error {
+
+	errs := ValidationErrors{}
+
+	report := func(mvmtNo int, mvmt Movement, field, problem string, err error) {
+		errs = append(errs, ValidationError{Subject: "movement", Index: mvmtNo, Id: mvmt.Id, Field: field, Problem: problem, Err: err})
+	}
+
+	seenIds := map[string]bool{}
+
+	for mvmtNo, mvmt := range movements {
+
+		if mvmt.Id == "" {
+			report(mvmtNo, mvmt, "Id", "is empty", ErrInvalidMovement)
+		} else if seenIds[mvmt.Id] {
+			report(mvmtNo, mvmt, "Id", "is used by another movement", ErrDuplicateMovement)
+		}
+		seenIds[mvmt.Id] = true
+
+		switch {
+		case mvmt.Type == "":
+			report(mvmtNo, mvmt, "Type", "is empty", ErrInvalidMovement)
+		case !v.isKnownMovementType(mvmt.Type):
+			report(mvmtNo, mvmt, "Type", strconv.Quote(mvmt.Type)+" is unknown", ErrInvalidMovement)
+		}
+
+		if mvmt.Date.IsZero() {
+			report(mvmtNo, mvmt, "Date", "is zero", ErrInvalidMovement)
+		}
+
+		if mvmt.Branch.Id == "" {
+			report(mvmtNo, mvmt, "Branch.Id", "is empty", ErrInvalidMovement)
+		}
+
+		if mvmt.User.Contractor != nil && *mvmt.User.Contractor == "" {
+			report(mvmtNo, mvmt, "User.Contractor", "is empty instead of nil", ErrInvalidMovement)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// isKnownMovementType checks movement type against the catalogue. Empty catalogue knows any type.
+func (v *Validator) isKnownMovementType(movementType string) bool {
+	return len(v.movementTypes) == 0 || v.movementTypes[movementType]
+}
+
+// priceModelAmounts returns non empty amounts of the price model by field name.
+func priceModelAmounts(model domain.PriceModel) map[string]string {
+
+	amounts := map[string]string{}
+
+	if model.Flat != "" {
+		amounts["Flat"] = model.Flat
+	}
+	for activityType, amount := range model.PerActivity {
+		if amount != "" {
+			amounts["PerActivity["+strconv.Quote(activityType)+"]"] = amount
+		}
+	}
+	for factor, amount := range model.WorkflowFactorSurcharges {
+		if amount != "" {
+			amounts["WorkflowFactorSurcharges["+strconv.Quote(factor)+"]"] = amount
+		}
+	}
+
+	return amounts
+}
+
+// sortedKeys returns keys of m in ascending order, so problems are reported in stable order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validityOverlaps checks whether validity periods of contract conditions have any moment in common.
+// Boundaries are compared by their wall clock time, as matching checks movement dates against them.
+func validityOverlaps(a, b domain.ContractCondition) bool {
+
+	// startsBefore tells whether the period starting at from begins before the period ending at to is over.
+	startsBefore := func(from, to time.Time) bool {
+		return from.IsZero() || to.IsZero() || wallClock(from).Before(wallClock(to))
+	}
+
+	return startsBefore(a.ValidFrom, b.ValidTo) && startsBefore(b.ValidFrom, a.ValidTo)
+}
@@ -0,0 +1,347 @@
+package application_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ivan-kostko/nrute-matches/application"
+	"github.com/ivan-kostko/nrute-matches/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidator_ValidateConditions(t *testing.T) {
+
+	testCases := []struct {
+		Alias          string
+		CatalogueIn    []string
+		ConditionsIn   []domain.ContractCondition
+		ExpectedFields []string
+		ExpectedErrs   []error
+	}{
+		{
+			Alias:       `Valid conditions`,
+			CatalogueIn: []string{"checkin", "parking"},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                 "Turnaround",
+					BranchIdentifier:   "6",
+					MovementActivities: []domain.MovementActivity{{Type: "checkin"}, {Type: "parking"}},
+				},
+			},
+			ExpectedFields: nil,
+			ExpectedErrs:   nil,
+		},
+		{
+			Alias:       `All problems are reported at once`,
+			CatalogueIn: []string{"checkin", "parking"},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                 "",
+					BranchIdentifier:   "",
+					MovementActivities: []domain.MovementActivity{{Type: ""}, {Type: "refuel"}},
+				},
+				domain.ContractCondition{
+					Id:                 "Turnaround",
+					BranchIdentifier:   "6",
+					MovementActivities: []domain.MovementActivity{{Type: "checkin"}, {Type: "parking"}},
+				},
+				domain.ContractCondition{
+					Id:               "Turnaround",
+					BranchIdentifier: "6",
+				},
+			},
+			ExpectedFields: []string{"Id", "BranchIdentifier", "MovementActivities[0].Type", "MovementActivities[1].Type", "Id", "MovementActivities"},
+			ExpectedErrs:   []error{application.ErrInvalidCondition, application.ErrDuplicateCondition},
+		},
+		{
+			Alias:       `Versions of the same condition`,
+			CatalogueIn: []string{"checkin", "parking"},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                 "Turnaround",
+					Version:            2018,
+					BranchIdentifier:   "6",
+					MovementActivities: []domain.MovementActivity{{Type: "checkin"}, {Type: "parking"}},
+					ValidFrom:          time.Date(2018, 01, 01, 0, 0, 0, 0, time.UTC),
+					ValidTo:            time.Date(2019, 01, 01, 0, 0, 0, 0, time.UTC),
+				},
+				domain.ContractCondition{
+					Id:                 "Turnaround",
+					Version:            2019,
+					BranchIdentifier:   "6",
+					MovementActivities: []domain.MovementActivity{{Type: "checkin"}, {Type: "parking"}},
+					ValidFrom:          time.Date(2019, 01, 01, 0, 0, 0, 0, time.UTC),
+				},
+				domain.ContractCondition{
+					Id:                 "Turnaround",
+					Version:            2020,
+					BranchIdentifier:   "6",
+					MovementActivities: []domain.MovementActivity{{Type: "checkin"}, {Type: "parking"}},
+					ValidFrom:          time.Date(2018, 06, 01, 0, 0, 0, 0, time.UTC),
+					ValidTo:            time.Date(2018, 07, 01, 0, 0, 0, 0, time.UTC),
+				},
+				domain.ContractCondition{
+					Id:                 "Parking",
+					BranchIdentifier:   "6",
+					MovementActivities: []domain.MovementActivity{{Type: "parking"}},
+					ValidFrom:          time.Date(2020, 01, 01, 0, 0, 0, 0, time.UTC),
+					ValidTo:            time.Date(2019, 01, 01, 0, 0, 0, 0, time.UTC),
+				},
+			},
+			ExpectedFields: []string{"Version", "ValidTo"},
+			ExpectedErrs:   []error{application.ErrInvalidCondition, application.ErrDuplicateCondition},
+		},
+		{
+			Alias:       `Versions of the same condition in different locations`,
+			CatalogueIn: []string{"checkin", "parking"},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                 "Turnaround",
+					Version:            2018,
+					BranchIdentifier:   "6",
+					MovementActivities: []domain.MovementActivity{{Type: "checkin"}, {Type: "parking"}},
+					ValidTo:            time.Date(2019, 01, 01, 0, 0, 0, 0, time.UTC),
+				},
+				domain.ContractCondition{
+					Id:                 "Turnaround",
+					Version:            2019,
+					BranchIdentifier:   "6",
+					MovementActivities: []domain.MovementActivity{{Type: "checkin"}, {Type: "parking"}},
+					ValidFrom:          time.Date(2019, 01, 01, 0, 0, 0, 0, time.FixedZone("CET", 3600)),
+				},
+			},
+			ExpectedFields: nil,
+			ExpectedErrs:   nil,
+		},
+		{
+			Alias:       `Activity cardinality`,
+			CatalogueIn: []string{"checkin", "wash", "parking"},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                 "Wash",
+					BranchIdentifier:   "6",
+					MovementActivities: []domain.MovementActivity{{Type: "checkin", Min: -1}, {Type: "wash", Min: 3, Max: 2}, {Type: "parking", Min: 1, Optional: true}},
+				},
+				domain.ContractCondition{
+					Id:                 "Turnaround",
+					BranchIdentifier:   "6",
+					MovementActivities: []domain.MovementActivity{{Type: "checkin"}, {Type: "wash", Min: 1, Max: 3}, {Type: "parking", Optional: true}},
+				},
+			},
+			ExpectedFields: []string{"MovementActivities[0].Min", "MovementActivities[1].Max", "MovementActivities[2].Min"},
+			ExpectedErrs:   []error{application.ErrInvalidCondition},
+		},
+		{
+			Alias:       `Instances of contract condition`,
+			CatalogueIn: []string{"checkin", "parking"},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                 "Turnaround",
+					BranchIdentifier:   "6",
+					MaxInstances:       -1,
+					MovementActivities: []domain.MovementActivity{{Type: "checkin"}, {Type: "parking"}},
+				},
+				domain.ContractCondition{
+					Id:                 "Parking",
+					BranchIdentifier:   "6",
+					MaxInstances:       3,
+					MovementActivities: []domain.MovementActivity{{Type: "parking"}},
+				},
+			},
+			ExpectedFields: []string{"MaxInstances"},
+			ExpectedErrs:   []error{application.ErrInvalidCondition},
+		},
+		{
+			Alias:       `Attribute patterns`,
+			CatalogueIn: []string{"checkin", "parking"},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                 "Turnaround",
+					BranchIdentifier:   "6",
+					VehicleType:        "car|[van",
+					WorkflowFactor:     "!express",
+					MovementActivities: []domain.MovementActivity{{Type: "checkin", Option: "*"}, {Type: "parking", Option: "!["}},
+				},
+			},
+			ExpectedFields: []string{"VehicleType", "MovementActivities[1].Option"},
+			ExpectedErrs:   []error{application.ErrInvalidCondition},
+		},
+		{
+			Alias:       `Suppressed contract conditions`,
+			CatalogueIn: []string{"checkin", "parking"},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                 "Special",
+					BranchIdentifier:   "6",
+					MovementActivities: []domain.MovementActivity{{Type: "checkin"}, {Type: "parking"}},
+					Exclusive:          true,
+					Suppresses:         []string{"Generic", "Unknown"},
+				},
+				domain.ContractCondition{
+					Id:                 "Generic",
+					BranchIdentifier:   "6",
+					MovementActivities: []domain.MovementActivity{{Type: "checkin"}, {Type: "parking"}},
+					Suppresses:         []string{"Special"},
+				},
+			},
+			ExpectedFields: []string{"Suppresses[1]", "Suppresses"},
+			ExpectedErrs:   []error{application.ErrInvalidCondition},
+		},
+		{
+			Alias:       `Price models`,
+			CatalogueIn: []string{"checkin", "parking"},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                 "Turnaround",
+					BranchIdentifier:   "6",
+					MovementActivities: []domain.MovementActivity{{Type: "checkin"}, {Type: "parking"}},
+					Price: domain.PriceModel{
+						Flat:                     "12,50",
+						PerActivity:              map[string]string{"checkin": "1.25", "parking": "1/3"},
+						WorkflowFactorSurcharges: map[string]string{"express": "5"},
+					},
+				},
+				domain.ContractCondition{
+					Id:                 "Parking",
+					BranchIdentifier:   "6",
+					MovementActivities: []domain.MovementActivity{{Type: "parking"}},
+					Price:              domain.PriceModel{Currency: "EUR", PerActivity: map[string]string{"parking": "0.10"}},
+				},
+			},
+			ExpectedFields: []string{"Price.Flat", `Price.PerActivity["parking"]`, "Price.Currency"},
+			ExpectedErrs:   []error{application.ErrInvalidPrice},
+		},
+		{
+			Alias:       `Price amounts which are not plain decimals`,
+			CatalogueIn: []string{"checkin", "parking"},
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                 "Turnaround",
+					BranchIdentifier:   "6",
+					MovementActivities: []domain.MovementActivity{{Type: "checkin"}, {Type: "parking"}},
+					Price: domain.PriceModel{
+						Currency:                 "EUR",
+						Flat:                     "0x10",
+						PerActivity:              map[string]string{"checkin": "1e3", "parking": "-2.50"},
+						WorkflowFactorSurcharges: map[string]string{"express": ".5", "premium": "+1"},
+					},
+				},
+			},
+			ExpectedFields: []string{"Price.Flat", `Price.PerActivity["checkin"]`, `Price.PerActivity["parking"]`, `Price.WorkflowFactorSurcharges["express"]`, `Price.WorkflowFactorSurcharges["premium"]`},
+			ExpectedErrs:   []error{application.ErrInvalidPrice},
+		},
+		{
+			Alias:       `Empty catalogue accepts any movement activity type`,
+			CatalogueIn: nil,
+			ConditionsIn: []domain.ContractCondition{
+				domain.ContractCondition{
+					Id:                 "Refuel",
+					BranchIdentifier:   "6",
+					MovementActivities: []domain.MovementActivity{{Type: "refuel"}, {Type: "refill_watertank"}},
+				},
+			},
+			ExpectedFields: nil,
+			ExpectedErrs:   nil,
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			err := application.NewValidator(tCase.CatalogueIn...).ValidateConditions(tCase.ConditionsIn)
+
+			assertValidationErrors(t, tCase.ExpectedFields, tCase.ExpectedErrs, err)
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func TestValidator_ValidateMovements(t *testing.T) {
+
+	testCases := []struct {
+		Alias          string
+		CatalogueIn    []string
+		MovementsIn    []application.Movement
+		ExpectedFields []string
+		ExpectedErrs   []error
+	}{
+		{
+			Alias:       `Valid movements`,
+			CatalogueIn: []string{"checkin", "parking"},
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:     "132456",
+					Type:   "checkin",
+					Date:   time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch: application.Branch{Id: "6"},
+				},
+			},
+			ExpectedFields: nil,
+			ExpectedErrs:   nil,
+		},
+		{
+			Alias:       `All problems are reported at once`,
+			CatalogueIn: []string{"checkin", "parking"},
+			MovementsIn: []application.Movement{
+				application.Movement{
+					Id:   "",
+					Type: "refuel",
+					User: application.User{Contractor: func() *string { s := ""; return &s }(), Id: "TheUserId"},
+				},
+				application.Movement{
+					Id:     "132456",
+					Type:   "checkin",
+					Date:   time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch: application.Branch{Id: "6"},
+				},
+				application.Movement{
+					Id:     "132456",
+					Type:   "",
+					Date:   time.Date(2018, 01, 31, 16, 59, 59, 999999990, time.UTC),
+					Branch: application.Branch{Id: "6"},
+				},
+			},
+			ExpectedFields: []string{"Id", "Type", "Date", "Branch.Id", "User.Contractor", "Id", "Type"},
+			ExpectedErrs:   []error{application.ErrInvalidMovement, application.ErrDuplicateMovement},
+		},
+	}
+
+	for _, tCase := range testCases {
+
+		testFn := func(t *testing.T) {
+
+			err := application.NewValidator(tCase.CatalogueIn...).ValidateMovements(tCase.MovementsIn)
+
+			assertValidationErrors(t, tCase.ExpectedFields, tCase.ExpectedErrs, err)
+		}
+
+		t.Run(tCase.Alias, testFn)
+	}
+}
+
+func assertValidationErrors(t *testing.T, expectedFields []string, expectedErrs []error, err error) {
+
+	if len(expectedFields) == 0 {
+		assert.NoError(t, err)
+		return
+	}
+
+	var validationErrs application.ValidationErrors
+	if !assert.True(t, errors.As(err, &validationErrs)) {
+		return
+	}
+
+	actualFields := []string{}
+	for _, e := range validationErrs {
+		actualFields = append(actualFields, e.Field)
+	}
+	assert.Equal(t, expectedFields, actualFields)
+
+	for _, expectedErr := range expectedErrs {
+		assert.ErrorIs(t, err, expectedErr)
+	}
+}
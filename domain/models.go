@@ -1,14 +1,52 @@
 package domain
 
+import "time"
+
 const (
 	Undefined_VehicleType    = ""
 	Undefined_WorkflowFactor = ""
 	Undefined_MovementOption = ""
 )
 
+// VehicleType, WorkflowFactor and MovementActivity.Option of contract conditions are patterns:
+//
+//	"car"         matches the value exactly
+//	"car|van"     matches any member of the set
+//	"*van"        matches glob pattern, as of path.Match
+//	"!express"    matches anything but the pattern following the negation, e.g. "!express|premium"
+const (
+	Pattern_SetSeparator = "|"
+	Pattern_Negation     = "!"
+)
+
+// Movement attributes which could be required to be equal across all movements of a bundle.
+const (
+	CoherentAttribute_VehicleId  = "vehicle_id"
+	CoherentAttribute_WorkflowId = "workflow_id"
+	CoherentAttribute_UserId     = "user_id"
+)
+
 type MovementActivity struct {
 	Type   string
 	Option string
+	// Min and Max limit the number of movements matched to the activity. Zero Min means one movement,
+	// zero Max means as many as Min, so the activity matches exactly one movement by default.
+	Min int
+	Max int
+	// Optional allows the activity to match no movements at all.
+	Optional bool
+}
+
+// PriceModel tells how a matched contract condition is priced.
+// Amounts are non-negative decimal strings, e.g. "12.50", so they are kept exact. Empty amount means zero.
+type PriceModel struct {
+	Currency string
+	// Flat is the price of the whole match.
+	Flat string
+	// PerActivity is the price of every matched movement by its movement activity type.
+	PerActivity map[string]string
+	// WorkflowFactorSurcharges is added for every matched movement by its workflow factor.
+	WorkflowFactorSurcharges map[string]string
 }
 
 type ContractCondition struct {
@@ -20,4 +58,30 @@ type ContractCondition struct {
 	MovementActivities   []MovementActivity
 	WorkflowType         string
 	WorkflowFactor       string
+	// Version distinguishes renegotiated editions of the same contract condition.
+	Version int
+	// ValidFrom (inclusive) and ValidTo (exclusive) limit the dates of movements the condition applies to.
+	// They are wall clock times of the movement branch. Zero value means unlimited.
+	ValidFrom time.Time
+	ValidTo   time.Time
+	// MaxSpan limits the time between the earliest and the latest movement of the bundle. Zero means unlimited.
+	MaxSpan time.Duration
+	// MinGap is the minimal time between any two movements of the bundle. Zero means no limit.
+	MinGap time.Duration
+	// CoherentAttributes lists movement attributes which must be equal for all movements of the bundle, e.g. CoherentAttribute_VehicleId.
+	CoherentAttributes []string
+	// Ordered requires movement dates of the bundle to follow the order of MovementActivities.
+	Ordered bool
+	// OrderTolerance lets a movement be earlier than the previous one by less than the tolerance and still count as ordered.
+	// Zero tolerance requires strictly increasing dates, so any positive tolerance accepts equal timestamps.
+	OrderTolerance time.Duration
+	// Priority makes combinations with higher total priority of matched conditions, bundle and single activity ones alike, win regardless of their score.
+	Priority int
+	// Exclusive condition, once matched, rules out matching of conditions listed by Id in Suppresses, or of all others if Suppresses is empty.
+	Exclusive  bool
+	Suppresses []string
+	// MaxInstances caps the number of matches of the condition in a single run. Zero means as many as movements allow.
+	MaxInstances int
+	// Price is the price model of the condition. Zero value leaves matches of the condition unpriced.
+	Price PriceModel
 }